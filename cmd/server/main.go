@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"crist-blog/internal/blogConfig"
+	"crist-blog/internal/denylist"
 	"crist-blog/internal/handler"
+	"crist-blog/internal/oauth"
+	"crist-blog/internal/ratelimit"
 	"crist-blog/internal/repository"
 	"crist-blog/internal/route"
+	"crist-blog/internal/scheduler"
 	"crist-blog/internal/service"
-	"crypto/rand"
-	"encoding/base64"
+	"crist-blog/internal/tokenizer"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -17,39 +26,173 @@ import (
 
 // 使用示例
 func main() {
-
-	bytes := make([]byte, 64)
-	_, err := rand.Read(bytes)
-	if err != nil {
-	}
-	jwtSecret := base64.URLEncoding.EncodeToString(bytes)
-
 	db := blogConfig.ConnectDB()
+	keys := blogConfig.LoadKeySet()
+
 	userRepo := repository.NewUserRepository(db)
 	authRepo := repository.NewRefreshTokenRepository(db)
 	postRepo := repository.NewPostRepository(db)
+	postRevisionRepo := repository.NewPostRevisionRepository(db)
+	postEditRepo := repository.NewPostEditRepository(db)
 	categoryRepo := repository.NewCategoryRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	userTokenRepo := repository.NewUserTokenRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	patRepo := repository.NewPersonalAccessTokenRepository(db)
+	identityRepo := repository.NewUserIdentityRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+	mailSender := blogConfig.LoadMailer()
+
+	passwordPolicy := service.PasswordPolicy{
+		MinLength:     blogConfig.EnvInt("PASSWORD_MIN_LENGTH", 8),
+		RequireUpper:  blogConfig.EnvBool("PASSWORD_REQUIRE_UPPER", false),
+		RequireLower:  blogConfig.EnvBool("PASSWORD_REQUIRE_LOWER", false),
+		RequireDigit:  blogConfig.EnvBool("PASSWORD_REQUIRE_DIGIT", true),
+		RequireSymbol: blogConfig.EnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+	}
+	appBaseURL := blogConfig.Env("APP_BASE_URL", "http://localhost:5173")
 
-	userService := service.NewUserService(userRepo)
-	authService := service.NewAuthService(userRepo, authRepo, jwtSecret)
-	postService := service.NewPostService(postRepo)
-	categoryService := service.NewCategoryService(categoryRepo)
+	auditService := service.NewAuditService(auditRepo)
+	loginGuard := service.NewLoginGuard(service.LoginGuardConfig{
+		Window:           blogConfig.EnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		FreeAttempts:     blogConfig.EnvInt("LOGIN_FREE_ATTEMPTS", 3),
+		BaseDelay:        blogConfig.EnvDuration("LOGIN_BASE_DELAY", time.Second),
+		MaxDelay:         blogConfig.EnvDuration("LOGIN_MAX_DELAY", time.Minute),
+		LockoutThreshold: blogConfig.EnvInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LockoutDuration:  blogConfig.EnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		IPMaxFailures:    blogConfig.EnvInt("LOGIN_IP_MAX_FAILURES", 30),
+	})
+	userService := service.NewUserService(userRepo, loginGuard, auditService, service.UserConfig{
+		RequireEmailVerification: blogConfig.EnvBool("REQUIRE_EMAIL_VERIFICATION", true),
+	})
+	registrationEnabled := blogConfig.EnvBool("REGISTRATION_ENABLED", false)
+	registrationService := service.NewRegistrationService(userRepo, invitationRepo, userTokenRepo, roleRepo, mailSender, auditService, service.RegistrationConfig{
+		Enabled:         registrationEnabled,
+		BaseURL:         appBaseURL,
+		VerificationTTL: blogConfig.EnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		InvitationTTL:   blogConfig.EnvDuration("INVITATION_TTL", 7*24*time.Hour),
+		PasswordPolicy:  passwordPolicy,
+	})
+	passwordService := service.NewPasswordService(userRepo, userTokenRepo, authRepo, mailSender, passwordPolicy, auditService, service.PasswordConfig{
+		BaseURL:  appBaseURL,
+		ResetTTL: blogConfig.EnvDuration("PASSWORD_RESET_TTL", time.Hour),
+	})
+	tokenDenylist := denylist.NewMemoryStore(time.Minute)
+	authService := service.NewAuthService(userRepo, authRepo, patRepo, keys, tokenDenylist, auditService, service.AuthConfig{
+		RefreshTokenSecret: blogConfig.LoadRefreshTokenSecret(),
+		MaxSessionsPerUser: blogConfig.EnvInt("MAX_SESSIONS_PER_USER", 5),
+	})
+	twoFactorService := service.NewTwoFactorService(userRepo, recoveryCodeRepo, authRepo, keys, tokenDenylist, loginGuard, auditService, blogConfig.Env("TOTP_ISSUER", "Crist Blog"))
+	searchTokenizer, err := tokenizer.New(blogConfig.Env("SEARCH_TOKENIZER", "chinese"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	searchService := service.NewSearchService(postRepo, searchTokenizer, service.SearchConfig{
+		TextSearchConfig: blogConfig.Env("SEARCH_TEXT_CONFIG", "simple"),
+	})
+	postService := service.NewPostService(postRepo, postRevisionRepo, searchService, auditService, service.PostConfig{
+		RevisionLimit:  blogConfig.EnvInt("POST_REVISION_LIMIT", 50),
+		TrashRetention: blogConfig.EnvDuration("POST_TRASH_RETENTION", 30*24*time.Hour),
+	})
+	profileService := service.NewProfileService(userRepo, postRepo, userTokenRepo, mailSender, auditService, service.ProfileConfig{
+		BaseURL:         appBaseURL,
+		VerificationTTL: blogConfig.EnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+	})
+	categoryService := service.NewCategoryService(categoryRepo, auditService)
+	rbacService := service.NewRBACService(userRepo, roleRepo)
+	userAdminService := service.NewUserAdminService(userRepo, authRepo, roleRepo, passwordService, loginGuard, passwordPolicy, auditService)
+	patService := service.NewPersonalAccessTokenService(patRepo, userRepo, rbacService, auditService)
+	oauthProviders := oauth.NewRegistry(context.Background(), blogConfig.LoadOAuthConfigs())
+	oauthService := service.NewOAuthService(userRepo, identityRepo, oauthProviders, keys, auditService, registrationEnabled)
 
-	postHandler := handler.NewPostHandler(postService, categoryService)
-	userHandler := handler.NewUserHandler(authService, userService)
+	postEditService := service.NewPostEditService(postEditRepo, service.PostEditConfig{
+		LockTTL: blogConfig.EnvDuration("POST_EDIT_LOCK_TTL", 2*time.Minute),
+	})
+	previewService := service.NewPreviewService(postRepo, postRevisionRepo, keys, auditService, service.PreviewConfig{
+		BaseURL:    appBaseURL,
+		DefaultTTL: blogConfig.EnvDuration("PREVIEW_LINK_TTL", 7*24*time.Hour),
+		MaxTTL:     blogConfig.EnvDuration("PREVIEW_LINK_MAX_TTL", 30*24*time.Hour),
+	})
+	postUnlockService := service.NewPostUnlockService(keys,
+		ratelimit.NewSlidingWindow(blogConfig.EnvDuration("POST_UNLOCK_WINDOW", 15*time.Minute)),
+		service.PostUnlockConfig{
+			TokenTTL:            blogConfig.EnvDuration("POST_UNLOCK_TTL", 24*time.Hour),
+			MaxAttempts:         blogConfig.EnvInt("POST_UNLOCK_MAX_ATTEMPTS", 5),
+			MaxPostAttempts:     blogConfig.EnvInt("POST_UNLOCK_MAX_POST_ATTEMPTS", 50),
+			PostAttemptInterval: blogConfig.EnvDuration("POST_UNLOCK_POST_INTERVAL", time.Second),
+			MaxPostWait:         blogConfig.EnvDuration("POST_UNLOCK_MAX_POST_WAIT", 10*time.Second),
+		})
+	postHandler := handler.NewPostHandler(postService, postEditService, previewService, postUnlockService, categoryService, rbacService)
+	userHandler := handler.NewUserHandler(authService, userService, twoFactorService)
 	categoryHandler := handler.NewCategoryHandler(categoryService)
+	jwksHandler := handler.NewJWKSHandler(keys)
+	auditHandler := handler.NewAuditHandler(auditService)
+	profileHandler := handler.NewProfileHandler(profileService)
+	userAdminHandler := handler.NewUserAdminHandler(userAdminService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	registrationHandler := handler.NewRegistrationHandler(registrationService)
+	passwordHandler := handler.NewPasswordHandler(passwordService)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+	patHandler := handler.NewPersonalAccessTokenHandler(patService)
+	oauthHandler := handler.NewOAuthHandler(oauthService, authService, twoFactorService,
+		blogConfig.Env("OAUTH_SUCCESS_REDIRECT", appBaseURL+"/oauth/callback"))
 
 	e := echo.New()
+	e.IPExtractor = blogConfig.LoadIPExtractor()
 	e.Use(middleware.BodyLimit("10M"))
-	route.SetupUserRoutes(e, userHandler, authService)
-	route.SetupBlogRouter(e, postHandler)
-	route.SetupCategoryRouter(e, categoryHandler)
+	route.SetupUserRoutes(e, userHandler, registrationHandler, passwordHandler, twoFactorHandler, patHandler, authService, rbacService)
+	route.SetupBlogRouter(e, postHandler, authService, rbacService)
+	route.SetupCategoryRouter(e, categoryHandler, authService, rbacService)
+	route.SetupWellKnownRouter(e, jwksHandler)
+	route.SetupOAuthRouter(e, oauthHandler, authService)
+	route.SetupAuditRouter(e, auditHandler, authService, rbacService)
+	route.SetupProfileRouter(e, profileHandler, authService)
+	route.SetupUserAdminRouter(e, userAdminHandler, authService, rbacService)
+	route.SetupSearchRouter(e, searchHandler, authService, rbacService)
+	route.SetupPostAdminRouter(e, postHandler, authService, rbacService)
+	jobs := scheduler.New()
+	for _, job := range []scheduler.Job{
+		{
+			Name:     "publish-scheduled-posts",
+			Interval: blogConfig.EnvDuration("SCHEDULED_PUBLISH_INTERVAL", time.Minute),
+			Run:      postService.PublishDuePosts,
+		},
+		{
+			Name:     "purge-expired-trash",
+			Interval: blogConfig.EnvDuration("POST_TRASH_PURGE_INTERVAL", time.Hour),
+			Run:      postService.PurgeExpiredTrash,
+		},
+	} {
+		if err := jobs.Add(job); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// 收到 SIGINT/SIGTERM 后停止接收新请求，等待进行中的请求和后台任务结束
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	jobs.Start(ctx)
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Println("🚀 Server is running on port", port)
-	e.Logger.Fatal(e.Start(":" + port))
+	go func() {
+		log.Println("🚀 Server is running on port", port)
+		if err := e.Start(":" + port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), blogConfig.EnvDuration("SHUTDOWN_TIMEOUT", 10*time.Second))
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("warning: server shutdown: %v", err)
+	}
+	jobs.Wait()
 }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -13,12 +14,20 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+var loadEnvOnce sync.Once
+
+// loadEnv 可选：加载 .env 文件（如果存在），只加载一次
+func loadEnv() {
+	loadEnvOnce.Do(func() {
+		err := godotenv.Load()
+		if err != nil {
+			log.Println("⚠️  No .env file found, using system environment variables")
+		}
+	})
+}
+
 func loadDBConfig() map[string]string {
-	// 可选：加载 .env 文件（如果存在）
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("⚠️  No .env file found, using system environment variables")
-	}
+	loadEnv()
 
 	return map[string]string{
 		"host":     getEnv("DB_HOST", "localhost"),
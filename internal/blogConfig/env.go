@@ -0,0 +1,55 @@
+package blogConfig
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+// Env 读取字符串配置，未设置时返回默认值
+func Env(key, defaultValue string) string {
+	loadEnv()
+	return getEnv(key, defaultValue)
+}
+
+// EnvInt 读取整数配置，格式错误时记录警告并返回默认值
+func EnvInt(key string, defaultValue int) int {
+	value := Env(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠️  invalid integer for %s: %q, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// EnvBool 读取布尔配置，支持 true/false/1/0 等写法
+func EnvBool(key string, defaultValue bool) bool {
+	value := Env(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("⚠️  invalid boolean for %s: %q, using %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+// EnvDuration 读取时长配置，格式同 time.ParseDuration（如 15m、24h）
+func EnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := Env(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("⚠️  invalid duration for %s: %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
@@ -0,0 +1,98 @@
+package blogConfig
+
+import (
+	"crist-blog/internal/keyset"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadKeySet 从环境变量读取 JWT 密钥配置并加载密钥
+//   - JWT_KEYS_DIR: 密钥目录，默认 ./keys
+//   - JWT_SIGNING_KID: 签名使用的 kid，默认取目录中 kid 字典序最大的私钥
+//   - JWT_PRIVATE_KEY / JWT_PRIVATE_KEY_ID: 直接以 PEM 形式传入的私钥
+//   - JWT_ALGORITHM: 自动生成密钥时使用的算法，EdDSA 或 RS256
+func LoadKeySet() *keyset.KeySet {
+	loadEnv()
+
+	ks, err := keyset.Load(keyset.Config{
+		Dir:           getEnv("JWT_KEYS_DIR", "keys"),
+		SigningKID:    getEnv("JWT_SIGNING_KID", ""),
+		PrivateKeyPEM: getEnv("JWT_PRIVATE_KEY", ""),
+		PrivateKeyID:  getEnv("JWT_PRIVATE_KEY_ID", ""),
+		Algorithm:     getEnv("JWT_ALGORITHM", keyset.AlgEdDSA),
+	})
+	if err != nil {
+		log.Fatalf("failed to load JWT keys: %v", err)
+	}
+	return ks
+}
+
+// refreshSecretFile 自动生成的刷新令牌密钥在密钥目录中的文件名
+const refreshSecretFile = "refresh_token.secret"
+
+// LoadRefreshTokenSecret 读取计算刷新令牌 HMAC 哈希所用的密钥 REFRESH_TOKEN_SECRET
+// 未配置时与签名密钥一样保存在 JWT_KEYS_DIR 中：首次启动生成并写入，之后重启继续使用；
+// 多实例部署需要共享该目录或显式配置 REFRESH_TOKEN_SECRET
+func LoadRefreshTokenSecret() []byte {
+	loadEnv()
+
+	if secret := getEnv("REFRESH_TOKEN_SECRET", ""); secret != "" {
+		return []byte(secret)
+	}
+	secret, err := loadOrCreateSecret(filepath.Join(getEnv("JWT_KEYS_DIR", "keys"), refreshSecretFile))
+	if err != nil {
+		log.Fatalf("failed to load refresh token secret: %v", err)
+	}
+	return secret
+}
+
+// loadOrCreateSecret 读取十六进制编码的密钥文件，不存在时生成新密钥并写入
+func loadOrCreateSecret(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		secret, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("%s: invalid secret", path)
+		}
+		return secret, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	// 先写临时文件再硬链接到目标路径：链接是原子的且目标已存在时失败，
+	// 共享目录的多个实例同时启动时只有一个写入成功，其余读取已写入的完整密钥
+	tmp, err := os.CreateTemp(filepath.Dir(path), refreshSecretFile+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(hex.EncodeToString(secret))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return loadOrCreateSecret(path)
+		}
+		return nil, err
+	}
+	log.Printf("🔑 Generated new refresh token secret in %s", path)
+	return secret, nil
+}
@@ -0,0 +1,44 @@
+package blogConfig
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreateSecretPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", refreshSecretFile)
+	first, err := loadOrCreateSecret(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 32 {
+		t.Fatalf("secret length %d, want 32", len(first))
+	}
+	// 重启后读取同一个密钥，已签发的刷新令牌继续有效
+	second, err := loadOrCreateSecret(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Fatal("secret changed between loads")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("secret file mode %o, want 600", perm)
+	}
+}
+
+func TestLoadOrCreateSecretRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), refreshSecretFile)
+	if err := os.WriteFile(path, []byte("not hex"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadOrCreateSecret(path); err == nil {
+		t.Fatal("expected error for corrupt secret file")
+	}
+}
@@ -0,0 +1,27 @@
+package blogConfig
+
+import (
+	"crist-blog/internal/mailer"
+	"log"
+)
+
+// LoadMailer 根据 MAIL_DRIVER 创建邮件发送器
+//   - smtp: 使用 SMTP_HOST / SMTP_PORT / SMTP_USER / SMTP_PASS / MAIL_FROM
+//   - log（默认）: 写入 MAIL_LOG_FILE，未设置时输出到日志
+func LoadMailer() mailer.Mailer {
+	switch driver := Env("MAIL_DRIVER", "log"); driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(
+			Env("SMTP_HOST", "localhost"),
+			EnvInt("SMTP_PORT", 587),
+			Env("SMTP_USER", ""),
+			Env("SMTP_PASS", ""),
+			Env("MAIL_FROM", "noreply@localhost"),
+		)
+	case "log":
+		return mailer.NewLogMailer(Env("MAIL_LOG_FILE", ""))
+	default:
+		log.Fatalf("unknown MAIL_DRIVER %q", driver)
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+package blogConfig
+
+import (
+	"crist-blog/internal/oauth"
+	"strings"
+)
+
+// LoadOAuthConfigs 读取第三方登录配置
+// OAUTH_PROVIDERS 为逗号分隔的提供方名称，每个提供方 NAME 的配置项为：
+//   - OAUTH_<NAME>_TYPE: oidc 或 github，名称为 github 时默认 github，否则默认 oidc
+//   - OAUTH_<NAME>_CLIENT_ID / OAUTH_<NAME>_CLIENT_SECRET
+//   - OAUTH_<NAME>_ISSUER: OIDC issuer 地址
+//   - OAUTH_<NAME>_REDIRECT_URL: 默认 API_BASE_URL/api/oauth/<name>/callback
+//   - OAUTH_<NAME>_SCOPES: 逗号分隔
+//   - OAUTH_<NAME>_AUTH_URL / OAUTH_<NAME>_TOKEN_URL / OAUTH_<NAME>_API_URL: 覆盖 GitHub 默认地址
+func LoadOAuthConfigs() []oauth.Config {
+	apiBaseURL := strings.TrimRight(Env("API_BASE_URL", "http://localhost:8080"), "/")
+
+	var configs []oauth.Config
+	for _, name := range strings.Split(Env("OAUTH_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		defaultType := "oidc"
+		if name == "github" {
+			defaultType = "github"
+		}
+		var scopes []string
+		for _, scope := range strings.Split(Env(prefix+"SCOPES", ""), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				scopes = append(scopes, scope)
+			}
+		}
+		configs = append(configs, oauth.Config{
+			Name:         name,
+			Type:         Env(prefix+"TYPE", defaultType),
+			ClientID:     Env(prefix+"CLIENT_ID", ""),
+			ClientSecret: Env(prefix+"CLIENT_SECRET", ""),
+			Issuer:       Env(prefix+"ISSUER", ""),
+			RedirectURL:  Env(prefix+"REDIRECT_URL", apiBaseURL+"/api/oauth/"+name+"/callback"),
+			Scopes:       scopes,
+			AuthURL:      Env(prefix+"AUTH_URL", ""),
+			TokenURL:     Env(prefix+"TOKEN_URL", ""),
+			APIURL:       Env(prefix+"API_URL", ""),
+		})
+	}
+	return configs
+}
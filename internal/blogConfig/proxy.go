@@ -0,0 +1,46 @@
+package blogConfig
+
+import (
+	"log"
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LoadIPExtractor 根据 TRUSTED_PROXIES 决定如何获取客户端 IP
+//   - 未设置（默认）: 直接使用连接的对端地址，忽略 X-Forwarded-For / X-Real-IP，防止伪造
+//   - 逗号分隔的 IP 或 CIDR: 只信任这些反向代理追加的 X-Forwarded-For
+//
+// 登录限流、两步验证、文章解锁和审计日志都依赖这里得到的 IP
+func LoadIPExtractor() echo.IPExtractor {
+	value := strings.TrimSpace(Env("TRUSTED_PROXIES", ""))
+	if value == "" {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			if ip := net.ParseIP(item); ip != nil && ip.To4() != nil {
+				item += "/32"
+			} else {
+				item += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(item)
+		if err != nil {
+			log.Fatalf("invalid TRUSTED_PROXIES entry %q: %v", item, err)
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
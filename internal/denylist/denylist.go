@@ -0,0 +1,59 @@
+package denylist
+
+import (
+	"sync"
+	"time"
+)
+
+// Store 记录在过期前被主动作废的令牌 ID（jti）
+// 单实例部署使用 MemoryStore，多实例部署可以实现基于 Redis 等共享存储的版本
+type Store interface {
+	// Add 将 jti 加入黑名单，ttl 之后自动移除（此时令牌本身也已过期）
+	Add(jti string, ttl time.Duration) error
+	// Contains 判断 jti 是否在黑名单中
+	Contains(jti string) (bool, error)
+}
+
+// MemoryStore 是基于内存的黑名单，过期条目由后台协程定期清理
+type MemoryStore struct {
+	mu    sync.RWMutex
+	items map[string]time.Time
+}
+
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{items: make(map[string]time.Time)}
+	go s.cleanupLoop(cleanupInterval)
+	return s
+}
+
+func (s *MemoryStore) Add(jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[jti] = time.Now().Add(ttl)
+	return nil
+}
+
+func (s *MemoryStore) Contains(jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	expiresAt, ok := s.items[jti]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (s *MemoryStore) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		s.mu.Lock()
+		for jti, expiresAt := range s.items {
+			if now.After(expiresAt) {
+				delete(s.items, jti)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const defaultAuditPageSize = 50
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// parseAuditFilter 从查询参数读取过滤条件，时间使用 RFC 3339 格式
+func parseAuditFilter(c echo.Context) (model.AuditLogFilter, error) {
+	filter := model.AuditLogFilter{
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("target_type"),
+		TargetID:   c.QueryParam("target_id"),
+		Outcome:    c.QueryParam("outcome"),
+		IPAddress:  c.QueryParam("ip"),
+	}
+	if v := c.QueryParam("actor_id"); v != "" {
+		actorID, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("Invalid actor_id")
+		}
+		filter.ActorID = &actorID
+	}
+	if v := c.QueryParam("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("Invalid from, expected RFC 3339 time")
+		}
+		filter.From = &from
+	}
+	if v := c.QueryParam("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("Invalid to, expected RFC 3339 time")
+		}
+		filter.To = &to
+	}
+	return filter, nil
+}
+
+// List 分页查询审计日志
+// GET /api/admin/audit-logs?action=&outcome=&actor_id=&target_type=&target_id=&ip=&from=&to=&page=&page_size=
+func (h *AuditHandler) List(c echo.Context) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	page, pageSize := parsePagination(c, defaultAuditPageSize)
+
+	logs, total, err := h.auditService.List(filter, page, pageSize)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, paginated(logs, total, page, pageSize))
+}
+
+// Export 以 CSV 格式导出符合条件的全部审计日志，过滤参数与 List 相同
+func (h *AuditHandler) Export(c echo.Context) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	res := c.Response()
+	filename := "audit-logs-" + time.Now().Format("20060102-150405") + ".csv"
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	res.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(res)
+	_ = w.Write([]string{"id", "created_at", "actor_id", "actor_name", "action", "target_type", "target_id", "ip_address", "user_agent", "outcome", "detail"})
+	err = h.auditService.Each(filter, func(entry *model.AuditLog) error {
+		actorID := ""
+		if entry.ActorID != nil {
+			actorID = entry.ActorID.String()
+		}
+		return w.Write([]string{
+			entry.ID.String(),
+			entry.CreatedAt.Format(time.RFC3339),
+			actorID,
+			csvSafe(entry.ActorName),
+			csvSafe(entry.Action),
+			csvSafe(entry.TargetType),
+			csvSafe(entry.TargetID),
+			csvSafe(entry.IPAddress),
+			csvSafe(entry.UserAgent),
+			csvSafe(entry.Outcome),
+			csvSafe(entry.Detail),
+		})
+	})
+	w.Flush()
+	if err == nil {
+		err = w.Error()
+	}
+	// 响应头已经发出，出错时只能中断输出
+	return err
+}
+
+// csvSafe 防止文本字段在表格软件中被当作公式执行
+// 除 = + - @ 外，开头的制表符和回车同样会被部分表格软件解析为公式
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
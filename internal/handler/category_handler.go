@@ -3,8 +3,10 @@ package handler
 import (
 	"crist-blog/internal/model"
 	"crist-blog/internal/service"
+	"errors"
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -32,3 +34,60 @@ func (h *CategoryHandler) ListAllCategories(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, categories)
 }
+
+func (h *CategoryHandler) Create(c echo.Context) error {
+	var req model.CategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if req.Name == "" || req.Slug == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name and slug are required"})
+	}
+	category := &model.Category{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+	}
+	if err := h.categoryService.Create(auditActor(c), category); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, category)
+}
+
+func (h *CategoryHandler) Update(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid category ID"})
+	}
+	var req model.CategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if req.Name == "" || req.Slug == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name and slug are required"})
+	}
+	category := &model.Category{
+		ID:          id,
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+	}
+	if err := h.categoryService.Update(auditActor(c), category); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, category)
+}
+
+func (h *CategoryHandler) Delete(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid category ID"})
+	}
+	if err := h.categoryService.Delete(auditActor(c), id); err != nil {
+		if errors.Is(err, service.ErrCategoryInUse) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
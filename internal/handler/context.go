@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"crist-blog/internal/service"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// currentUserID 读取 AuthMiddleware 写入上下文的用户ID
+func currentUserID(c echo.Context) (uuid.UUID, bool) {
+	userID, ok := c.Get("user_id").(uuid.UUID)
+	return userID, ok
+}
+
+// currentSessionID 读取 AuthMiddleware 写入上下文的会话ID，没有时返回 uuid.Nil
+func currentSessionID(c echo.Context) uuid.UUID {
+	sessionID, _ := c.Get("session_id").(uuid.UUID)
+	return sessionID
+}
+
+// currentAccessToken 读取当前访问令牌的 jti 和过期时间
+func currentAccessToken(c echo.Context) (string, time.Time) {
+	jti, _ := c.Get("jti").(string)
+	expiresAt, _ := c.Get("token_expires_at").(time.Time)
+	return jti, expiresAt
+}
+
+// scopeAllows 判断当前凭证是否允许使用某项权限
+// JWT 登录不受限制，个人访问令牌只能使用其 scopes 内的权限
+func scopeAllows(c echo.Context, permission string) bool {
+	scopes, scoped := c.Get("token_scopes").([]string)
+	return !scoped || slices.Contains(scopes, permission)
+}
+
+// auditActor 构造当前请求的审计主体，未登录时 UserID 为 uuid.Nil
+func auditActor(c echo.Context) service.Actor {
+	userID, _ := currentUserID(c)
+	return service.Actor{
+		UserID:    userID,
+		IP:        c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	}
+}
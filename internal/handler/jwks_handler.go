@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"crist-blog/internal/keyset"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type JWKSHandler struct {
+	keys *keyset.KeySet
+}
+
+func NewJWKSHandler(keys *keyset.KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+// JWKS 公开当前所有验签公钥（包括轮换中的旧密钥）
+func (h *JWKSHandler) JWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.keys.JWKS())
+}
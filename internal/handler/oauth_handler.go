@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const oauthStateCookie = "oauth_state"
+
+type OAuthHandler struct {
+	oauthService     *service.OAuthService
+	authService      *service.AuthService
+	twoFactorService *service.TwoFactorService
+	// redirectURL 登录完成后跳转的前端页面，结果放在 URL fragment 中
+	redirectURL string
+}
+
+func NewOAuthHandler(
+	oauthService *service.OAuthService,
+	authService *service.AuthService,
+	twoFactorService *service.TwoFactorService,
+	redirectURL string) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService:     oauthService,
+		authService:      authService,
+		twoFactorService: twoFactorService,
+		redirectURL:      redirectURL,
+	}
+}
+
+func (h *OAuthHandler) Providers(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.oauthService.Providers())
+}
+
+// Login 跳转到第三方授权页，state/PKCE/nonce 保存在签名的 Cookie 中
+func (h *OAuthHandler) Login(c echo.Context) error {
+	authURL, stateToken, err := h.oauthService.Begin(c.Param("provider"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    stateToken,
+		HttpOnly: true,
+		Secure:   true,
+		// 回调是第三方站点发起的顶级跳转，Strict 模式下浏览器不会带上 Cookie
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/api/oauth",
+		MaxAge:   int(service.OAuthStateExpire.Seconds()),
+	})
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 处理第三方回调，登录成功后跳转回前端并在 fragment 中携带访问令牌
+// 用户启用了两步验证时携带 mfa_token，由前端继续调用 /api/login/2fa
+func (h *OAuthHandler) Callback(c echo.Context) error {
+	provider := c.Param("provider")
+	if errParam := c.QueryParam("error"); errParam != "" {
+		return h.redirect(c, url.Values{"error": {errParam}})
+	}
+
+	cookie, err := c.Cookie(oauthStateCookie)
+	if err != nil {
+		return h.redirect(c, url.Values{"error": {service.ErrInvalidOAuthState.Error()}})
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    "",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/api/oauth",
+		MaxAge:   -1,
+	})
+
+	user, err := h.oauthService.Complete(c.Request().Context(), auditActor(c), provider, c.QueryParam("code"), c.QueryParam("state"), cookie.Value)
+	if err != nil {
+		return h.redirect(c, url.Values{"error": {err.Error()}})
+	}
+
+	if user.TOTPEnabled {
+		challenge, err := h.twoFactorService.IssueChallenge(user)
+		if err != nil {
+			return h.redirect(c, url.Values{"error": {"Failed to generate tokens"}})
+		}
+		return h.redirect(c, url.Values{"mfa_token": {challenge}})
+	}
+
+	accessToken, refreshToken, err := h.authService.GenerateTokens(user, c.Request().UserAgent(), c.RealIP())
+	if errors.Is(err, service.ErrAccountDisabled) {
+		return h.redirect(c, url.Values{"error": {err.Error()}})
+	}
+	if err != nil {
+		return h.redirect(c, url.Values{"error": {"Failed to generate tokens"}})
+	}
+	setRefreshCookie(c, refreshToken)
+	return h.redirect(c, url.Values{"access_token": {accessToken}})
+}
+
+// redirect 跳转回前端，参数放在 fragment 中以免出现在服务器日志和 Referer 里
+func (h *OAuthHandler) redirect(c echo.Context, values url.Values) error {
+	return c.Redirect(http.StatusFound, h.redirectURL+"#"+values.Encode())
+}
+
+func (h *OAuthHandler) ListIdentities(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	identities, err := h.oauthService.ListIdentities(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, identities)
+}
+
+func (h *OAuthHandler) Unlink(c echo.Context) error {
+	if _, ok := currentUserID(c); !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	identityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid identity ID"})
+	}
+	if err := h.oauthService.Unlink(auditActor(c), identityID); err != nil {
+		if errors.Is(err, service.ErrIdentityNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
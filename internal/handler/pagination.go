@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 200
+)
+
+// parsePagination 读取 page 和 page_size 查询参数，缺省或非法时使用默认值
+func parsePagination(c echo.Context, defaultSize int) (page, pageSize int) {
+	page, _ = strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ = strconv.Atoi(c.QueryParam("page_size"))
+	if pageSize < 1 {
+		pageSize = defaultSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
+
+// paginated 分页接口的统一响应结构
+func paginated(items interface{}, total int64, page, pageSize int) map[string]interface{} {
+	return map[string]interface{}{
+		"items":     items,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	}
+}
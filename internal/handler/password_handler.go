@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PasswordHandler struct {
+	passwordService *service.PasswordService
+}
+
+func NewPasswordHandler(passwordService *service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{
+		passwordService: passwordService,
+	}
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" validate:"required"`
+}
+
+func (h *PasswordHandler) ForgotPassword(c echo.Context) error {
+	req := new(forgotPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	h.passwordService.ForgotPassword(auditActor(c), req.Email)
+	return c.JSON(http.StatusOK, map[string]string{"message": "如果该邮箱已注册，重置邮件已发送"})
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (h *PasswordHandler) ResetPassword(c echo.Context) error {
+	req := new(resetPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	if err := h.passwordService.ResetPassword(auditActor(c), req.Token, req.Password); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "密码已重置，请重新登录"})
+}
+
+type changePasswordRequest struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+func (h *PasswordHandler) ChangePassword(c echo.Context) error {
+	if _, ok := currentUserID(c); !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	req := new(changePasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	if err := h.passwordService.ChangePassword(auditActor(c), req.OldPassword, req.NewPassword); err != nil {
+		if errors.Is(err, service.ErrWrongPassword) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	clearRefreshCookie(c)
+	return c.JSON(http.StatusOK, map[string]string{"message": "密码已修改，请重新登录"})
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type PersonalAccessTokenHandler struct {
+	patService *service.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(patService *service.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{
+		patService: patService,
+	}
+}
+
+func (h *PersonalAccessTokenHandler) Create(c echo.Context) error {
+	if _, ok := currentUserID(c); !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	var req model.CreatePersonalAccessTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	token, pat, err := h.patService.Create(auditActor(c), &req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"token": token,
+		"info":  pat,
+	})
+}
+
+func (h *PersonalAccessTokenHandler) List(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	tokens, err := h.patService.List(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+func (h *PersonalAccessTokenHandler) Revoke(c echo.Context) error {
+	if _, ok := currentUserID(c); !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid token ID"})
+	}
+	if err := h.patService.Revoke(auditActor(c), id); err != nil {
+		if errors.Is(err, service.ErrTokenNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+var errBulkForbidden = errors.New("Forbidden")
+
+// bulkFilterQuery 把批量操作的过滤条件转换为文章查询条件
+func bulkFilterQuery(f *model.BulkPostFilter) (*model.PostQuery, error) {
+	q := &model.PostQuery{Tag: f.Tag}
+	if f.CategoryID != "" {
+		id, err := uuid.Parse(f.CategoryID)
+		if err != nil {
+			return nil, errors.New("Invalid category_id")
+		}
+		q.CategoryID = &id
+	}
+	if f.AuthorID != "" {
+		id, err := uuid.Parse(f.AuthorID)
+		if err != nil {
+			return nil, errors.New("Invalid author_id")
+		}
+		q.AuthorID = &id
+	}
+	if f.Status != "" {
+		switch model.PostStatus(f.Status) {
+		case model.Draft, model.Published, model.Private, model.Scheduled, model.Protected:
+			q.Status = model.PostStatus(f.Status)
+		default:
+			return nil, errors.New("Invalid status")
+		}
+	}
+	var err error
+	if q.From, err = parseDate("from", f.From); err != nil {
+		return nil, err
+	}
+	if q.To, err = parseDate("to", f.To); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Bulk 对一组文章或符合条件的文章执行同一操作，逐篇返回结果
+// 没有 post:edit:any 权限的用户按条件选择时只会选中自己的文章
+// POST /api/posts/bulk
+func (h *PostHandler) Bulk(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	var req model.BulkPostRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if (len(req.IDs) > 0) == (req.Filter != nil) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Exactly one of ids and filter is required"})
+	}
+
+	// 回收站只对拥有 post:delete:any 的用户开放，批量恢复与单篇恢复使用同一权限
+	if req.Action == model.BulkRestore {
+		allowed, err := h.hasPermission(c, userID, model.PermPostDeleteAny)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if !allowed {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "No permission to restore posts"})
+		}
+	}
+	if req.Action == model.BulkSetStatus && isPublishing(model.PostStatus(req.Status)) {
+		allowed, err := h.hasPermission(c, userID, model.PermPostPublish)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if !allowed {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "No permission to publish posts"})
+		}
+	}
+	if req.Action == model.BulkSetCategory {
+		if id, err := uuid.Parse(req.CategoryID); err == nil {
+			if _, err := h.categoryService.GetNameByID(id); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Category not found"})
+			}
+		}
+	}
+
+	ids := req.IDs
+	if req.Filter != nil {
+		q, err := bulkFilterQuery(req.Filter)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		canEditAll, err := h.hasPermission(c, userID, model.PermPostEditAny)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if !canEditAll {
+			q.AuthorID = &userID
+		}
+		if ids, err = h.postService.ResolveBulkIDs(req.Action, q); err != nil {
+			return bulkError(c, err)
+		}
+	}
+
+	// 权限只查询一次，逐篇检查时只比较作者，与 canModify 的规则一致
+	ownPerm, anyPerm := model.PermPostEditOwn, model.PermPostEditAny
+	if req.Action == model.BulkDelete || req.Action == model.BulkRestore {
+		ownPerm, anyPerm = model.PermPostDeleteOwn, model.PermPostDeleteAny
+	}
+	canOwn, err := h.hasPermission(c, userID, ownPerm)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	canAny, err := h.hasPermission(c, userID, anyPerm)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	result, err := h.postService.BulkApply(auditActor(c), &req, ids, func(post *model.Post) error {
+		if canAny || (canOwn && post.UserID == userID) {
+			return nil
+		}
+		return errBulkForbidden
+	})
+	if err != nil {
+		return bulkError(c, err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func bulkError(c echo.Context, err error) error {
+	if errors.Is(err, service.ErrInvalidBulkRequest) || errors.Is(err, service.ErrBulkNoPosts) ||
+		errors.Is(err, service.ErrBulkTooManyPosts) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// setPostETag 以文章版本号作为 ETag，客户端修改时通过 If-Match 带回
+func setPostETag(c echo.Context, post *model.Post) {
+	c.Response().Header().Set("ETag", `"`+strconv.Itoa(post.Version)+`"`)
+}
+
+// ifMatchVersion 解析 If-Match 头中的版本号，支持 "3"、W/"3" 和 3
+func ifMatchVersion(c echo.Context) (int, bool) {
+	v := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if v == "" {
+		return 0, false
+	}
+	v = strings.Trim(strings.TrimPrefix(v, "W/"), `"`)
+	version, err := strconv.Atoi(v)
+	return version, err == nil && version > 0
+}
+
+// GetForEdit 读取文章的完整内容供编辑器使用，响应头带有 ETag
+// GET /api/posts/:id/edit
+func (h *PostHandler) GetForEdit(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	setPostETag(c, post)
+	return c.JSON(http.StatusOK, post)
+}
+
+// GetLock 查看文章当前的编辑锁，没有人编辑时返回 204
+// GET /api/posts/:id/lock
+func (h *PostHandler) GetLock(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	lock, err := h.editService.GetLock(post.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if lock == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
+	return c.JSON(http.StatusOK, lock)
+}
+
+// AcquireLock 获取编辑锁，被他人持有时返回 423 和当前持有者，可传 takeover 强制接管
+// POST /api/posts/:id/lock
+func (h *PostHandler) AcquireLock(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	var req model.AcquireLockRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID, _ := currentUserID(c)
+	lock, err := h.editService.AcquireLock(post.ID, userID, req.Takeover)
+	if err != nil {
+		if errors.Is(err, service.ErrPostLocked) {
+			return c.JSON(http.StatusLocked, map[string]interface{}{"error": err.Error(), "lock": lock})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, lock)
+}
+
+// HeartbeatLock 续期当前用户持有的编辑锁，锁已被接管时返回 409
+// PUT /api/posts/:id/lock
+func (h *PostHandler) HeartbeatLock(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	userID, _ := currentUserID(c)
+	lock, err := h.editService.Heartbeat(post.ID, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrLockNotHeld) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, lock)
+}
+
+// ReleaseLock 释放当前用户持有的编辑锁
+// DELETE /api/posts/:id/lock
+func (h *PostHandler) ReleaseLock(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	userID, _ := currentUserID(c)
+	if err := h.editService.ReleaseLock(post.ID, userID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetAutosave 读取当前用户的自动保存草稿
+// GET /api/posts/:id/autosave
+func (h *PostHandler) GetAutosave(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	userID, _ := currentUserID(c)
+	autosave, err := h.editService.GetAutosave(post.ID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Autosave not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, autosave)
+}
+
+// Autosave 保存当前用户的编辑草稿，不修改文章本身
+// PUT /api/posts/:id/autosave
+func (h *PostHandler) Autosave(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	var req model.AutosaveRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if req.BaseVersion == 0 {
+		req.BaseVersion = post.Version
+	}
+	userID, _ := currentUserID(c)
+	autosave, err := h.editService.Autosave(post.ID, userID, &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, autosave)
+}
+
+// DiscardAutosave 丢弃当前用户的自动保存草稿
+// DELETE /api/posts/:id/autosave
+func (h *PostHandler) DiscardAutosave(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	userID, _ := currentUserID(c)
+	if err := h.editService.DiscardAutosave(post.ID, userID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -3,50 +3,84 @@ package handler
 import (
 	"crist-blog/internal/model"
 	"crist-blog/internal/service"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 type PostHandler struct {
 	postService     *service.PostService
+	editService     *service.PostEditService
+	previewService  *service.PreviewService
+	unlockService   *service.PostUnlockService
 	categoryService *service.CategoryService
+	rbacService     *service.RBACService
 }
 
-func NewPostHandler(postService *service.PostService, categoryService *service.CategoryService) *PostHandler {
+func NewPostHandler(postService *service.PostService, editService *service.PostEditService, previewService *service.PreviewService, unlockService *service.PostUnlockService, categoryService *service.CategoryService, rbacService *service.RBACService) *PostHandler {
 	return &PostHandler{
 		postService:     postService,
+		editService:     editService,
+		previewService:  previewService,
+		unlockService:   unlockService,
 		categoryService: categoryService,
+		rbacService:     rbacService,
 	}
 }
 
+// hasPermission 判断当前用户是否拥有某项权限，使用个人访问令牌时还受令牌 scopes 限制
+func (h *PostHandler) hasPermission(c echo.Context, userID uuid.UUID, permission string) (bool, error) {
+	if !scopeAllows(c, permission) {
+		return false, nil
+	}
+	return h.rbacService.UserHasPermission(userID, permission)
+}
+
+// canModify 判断用户能否修改或删除文章：作者本人需要 ownPerm 或 anyPerm，其他人需要 anyPerm
+func (h *PostHandler) canModify(c echo.Context, userID uuid.UUID, post *model.Post, ownPerm, anyPerm string) (bool, error) {
+	if post.UserID == userID {
+		allowed, err := h.hasPermission(c, userID, ownPerm)
+		if err != nil || allowed {
+			return allowed, err
+		}
+	}
+	return h.hasPermission(c, userID, anyPerm)
+}
+
 func (h *PostHandler) CreatePost(c echo.Context) error {
 	var req model.CreatePostRequest
-	userId := "00000000-0000-0000-0000-000000000001"
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
-	if req.UserID == "" {
-		req.UserID = userId
-	}
-	if _, err := uuid.Parse(req.UserID); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
-	}
 	if _, err := uuid.Parse(req.CategoryID); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid category ID"})
 	}
 	defaultValue := 0
 
-	userID, err := uuid.Parse(userId)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
-	}
 	if req.Title == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Title is required"})
 	}
+	if isPublishing(model.PostStatus(req.Status)) {
+		allowed, err := h.hasPermission(c, userID, model.PermPostPublish)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if !allowed {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "No permission to publish posts"})
+		}
+	}
 	post := &model.Post{
 		UserID:          userID,
 		Title:           req.Title,
@@ -61,12 +95,16 @@ func (h *PostHandler) CreatePost(c echo.Context) error {
 		Views:           defaultValue,
 		Likes:           defaultValue,
 		Thumbnail:       req.Thumbnail,
+		PublishedAt:     req.PublishedAt,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
-	if err := h.postService.CreatePost(post); err != nil {
+	if err := setPostPassword(post, req.Password); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
+	if err := h.postService.CreatePost(auditActor(c), post); err != nil {
+		return postSaveError(c, err)
+	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Post created successfully", "id": post.ID})
 }
@@ -82,14 +120,41 @@ func (h *PostHandler) Get(c echo.Context) error {
 	return c.JSON(http.StatusOK, post)
 }
 
+// GetBlogToViewers 公开的文章详情，只返回已发布的文章
+// 草稿和私密文章与不存在的文章一样返回 404，需要分享时使用预览链接
 func (h *PostHandler) GetBlogToViewers(c echo.Context) error {
-	idStr := c.Param("id")
-	id64, err := strconv.ParseUint(idStr, 10, 64)
-	id := uint(id64)
-	post, err := h.postService.GetByID(id)
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
+	}
+	post, err := h.postService.GetPublishedByID(id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return h.respondDetail(c, post)
+}
+
+// GetBySlug 按 slug 获取已发布的文章，旧 slug 永久重定向到当前地址
+// GET /api/posts/slug/:slug
+func (h *PostHandler) GetBySlug(c echo.Context) error {
+	post, redirect, err := h.postService.GetPublishedBySlug(c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
+	if redirect != "" {
+		return c.Redirect(http.StatusMovedPermanently, "/api/posts/slug/"+url.PathEscape(redirect))
+	}
+	return h.respondDetail(c, post)
+}
+
+// postDetail 组装详情页数据，分类不存在时显示为未分类
+func (h *PostHandler) postDetail(post *model.Post) *model.PostDetail {
 	dateStr := ""
 	if post.PublishedAt != nil {
 		// 格式：2025年12月15日
@@ -101,9 +166,10 @@ func (h *PostHandler) GetBlogToViewers(c echo.Context) error {
 	if err != nil {
 		categoryName = "未分类"
 	}
-	var postToViewers = &model.PostDetail{
+	return &model.PostDetail{
 		ID:              post.ID,
 		Title:           post.Title,
+		Slug:            post.Slug,
 		Content:         post.Content,
 		Date:            dateStr,
 		Tags:            post.Tags,
@@ -114,7 +180,26 @@ func (h *PostHandler) GetBlogToViewers(c echo.Context) error {
 		MetaTitle:       post.MetaTitle,
 		MetaDescription: post.MetaDescription,
 	}
-	return c.JSON(http.StatusOK, postToViewers)
+}
+
+// isPublishing 发布、定时发布和密码保护发布都需要 post:publish 权限
+func isPublishing(status model.PostStatus) bool {
+	return status == model.Published || status == model.Scheduled || status == model.Protected
+}
+
+// postSaveError 把保存文章时的校验错误映射为对应的状态码
+func postSaveError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidSlug), errors.Is(err, service.ErrInvalidSchedule),
+		errors.Is(err, service.ErrPostPasswordRequired):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrSlugTaken), errors.Is(err, service.ErrPostVersionConflict):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrPostPreconditionRequired):
+		return c.JSON(http.StatusPreconditionRequired, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
 }
 
 func (h *PostHandler) Update(c echo.Context) error {
@@ -128,10 +213,36 @@ func (h *PostHandler) Update(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
+	if _, err := uuid.Parse(req.CategoryID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid category ID"})
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	existing, err := h.postService.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+	}
+	allowed, err := h.canModify(c, userID, existing, model.PermPostEditOwn, model.PermPostEditAny)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !allowed {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Forbidden"})
+	}
+	if isPublishing(model.PostStatus(req.Status)) && existing.Status != model.PostStatus(req.Status) {
+		allowed, err := h.hasPermission(c, userID, model.PermPostPublish)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if !allowed {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "No permission to publish posts"})
+		}
+	}
 
 	post := &model.Post{
 		ID:              id,
-		UserID:          uuid.MustParse(req.UserID), // 注意：实际应从 token 获取，不应由前端传
 		Title:           req.Title,
 		Slug:            req.Slug,
 		Content:         req.Content,
@@ -142,16 +253,28 @@ func (h *PostHandler) Update(c echo.Context) error {
 		MetaTitle:       req.MetaTitle,
 		MetaDescription: req.MetaDescription,
 		PublishedAt:     nil,
+		Version:         req.Version,
 	}
 	if req.PublishedAt != nil {
 		post.PublishedAt = req.PublishedAt
 	}
-
-	if err := h.postService.Update(post); err != nil {
+	if version, ok := ifMatchVersion(c); ok {
+		post.Version = version
+	}
+	if err := setPostPassword(post, req.Password); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
+	if err := h.postService.Update(auditActor(c), post); err != nil {
+		return postSaveError(c, err)
+	}
+	// 正式保存后自动保存的草稿已经没有意义
+	if err := h.editService.DiscardAutosave(id, userID); err != nil {
+		log.Printf("warning: failed to discard autosave of post %d: %v", id, err)
+	}
+
 	updated, _ := h.postService.GetByID(id)
+	setPostETag(c, updated)
 	return c.JSON(http.StatusOK, updated)
 }
 
@@ -162,7 +285,22 @@ func (h *PostHandler) Delete(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
-	if err := h.postService.Delete(id); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	existing, err := h.postService.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+	}
+	allowed, err := h.canModify(c, userID, existing, model.PermPostDeleteOwn, model.PermPostDeleteAny)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !allowed {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Forbidden"})
+	}
+	if err := h.postService.Delete(auditActor(c), id); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
@@ -181,29 +319,135 @@ func (h *PostHandler) List(c echo.Context) error {
 }
 
 func (h *PostHandler) ListToFrontend(c echo.Context) error {
-	posts, err := h.postService.List()
+	posts, err := h.postService.ListPublished()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 	var blogPosts []*model.PostFrontend
 	for _, post := range posts {
-		if post.Status != model.Published {
-			continue
+		date := post.CreatedAt
+		if post.PublishedAt != nil {
+			date = *post.PublishedAt
 		}
 		blogPosts = append(blogPosts, &model.PostFrontend{
 			ID:        post.ID,
 			Title:     post.Title,
 			Tags:      post.Tags,
-			Date:      post.PublishedAt.Format("2006-01-02"),
+			Date:      date.Format("2006-01-02"),
 			Excerpt:   post.Excerpt,
 			Views:     post.Views,
 			Likes:     post.Likes,
 			Thumbnail: post.Thumbnail,
+			Protected: post.Status == model.Protected,
 		})
 	}
 	return c.JSON(http.StatusOK, blogPosts)
 }
 
+// parsePostQuery 读取文章列表的查询参数
+// 日期支持 RFC 3339 或 2006-01-02 格式
+func parsePostQuery(c echo.Context) (*model.PostQuery, error) {
+	q := &model.PostQuery{
+		Tag:       c.QueryParam("tag"),
+		Sort:      c.QueryParam("sort"),
+		Ascending: c.QueryParam("order") == "asc",
+	}
+	if v := c.QueryParam("category_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, errors.New("Invalid category_id")
+		}
+		q.CategoryID = &id
+	}
+	if v := c.QueryParam("author_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, errors.New("Invalid author_id")
+		}
+		q.AuthorID = &id
+	}
+	var err error
+	if q.From, err = parseDateParam(c, "from"); err != nil {
+		return nil, err
+	}
+	if q.To, err = parseDateParam(c, "to"); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func parseDateParam(c echo.Context, name string) (*time.Time, error) {
+	return parseDate(name, c.QueryParam(name))
+}
+
+// parseDate 解析 RFC 3339 或 2006-01-02 格式的日期，v 为空时返回 nil
+func parseDate(name, v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if t, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			return nil, fmt.Errorf("Invalid %s", name)
+		}
+	}
+	return &t, nil
+}
+
+// queryPosts 执行查询并返回分页结果
+func (h *PostHandler) queryPosts(c echo.Context, q *model.PostQuery) error {
+	page, pageSize := parsePagination(c, defaultPageSize)
+	q.Limit = pageSize
+	result, err := h.postService.QueryPosts(q, page, c.QueryParam("cursor"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidPostSort) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// Query 公开的文章列表，只返回已发布和受密码保护的文章
+// GET /api/posts?category_id=&tag=&author_id=&from=&to=&sort=published_at|views|likes&order=asc|desc&page=&page_size=&cursor=
+func (h *PostHandler) Query(c echo.Context) error {
+	q, err := parsePostQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	q.Listed = true
+	return h.queryPosts(c, q)
+}
+
+// QueryManaged 后台文章列表，可按状态筛选
+// 没有 post:edit:any 权限的用户只能看到自己的文章
+func (h *PostHandler) QueryManaged(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	q, err := parsePostQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if status := c.QueryParam("status"); status != "" {
+		switch model.PostStatus(status) {
+		case model.Draft, model.Published, model.Private, model.Scheduled, model.Protected:
+			q.Status = model.PostStatus(status)
+		default:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid status"})
+		}
+	}
+	canViewAll, err := h.hasPermission(c, userID, model.PermPostEditAny)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !canViewAll {
+		q.AuthorID = &userID
+	}
+	return h.queryPosts(c, q)
+}
+
 func (h *PostHandler) GetHotPosts(c echo.Context) error {
 	posts, err := h.postService.GetHotPosts()
 	if err != nil {
@@ -216,11 +460,12 @@ func (h *PostHandler) GetHotPosts(c echo.Context) error {
 			category = "未分类"
 		}
 		blogPosts = append(blogPosts, &model.HotPostFrontend{
-			ID:       post.ID,
-			Title:    post.Title,
-			Category: category,
-			Date:     post.CreatedAt.Format("2006-01-02"),
-			Excerpt:  post.Excerpt,
+			ID:        post.ID,
+			Title:     post.Title,
+			Category:  category,
+			Date:      post.CreatedAt.Format("2006-01-02"),
+			Excerpt:   post.Excerpt,
+			Protected: post.Status == model.Protected,
 		})
 	}
 	return c.JSON(http.StatusOK, blogPosts)
@@ -238,10 +483,11 @@ func (h *PostHandler) GetLatestPosts(c echo.Context) error {
 			category = "未分类"
 		}
 		blogPosts = append(blogPosts, &model.LatestPostFrontend{
-			ID:       post.ID,
-			Title:    post.Title,
-			Category: category,
-			Date:     post.CreatedAt.Format("2006-01-02"),
+			ID:        post.ID,
+			Title:     post.Title,
+			Category:  category,
+			Date:      post.CreatedAt.Format("2006-01-02"),
+			Protected: post.Status == model.Protected,
 		})
 	}
 	return c.JSON(http.StatusOK, blogPosts)
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// parseUintParam 解析路径参数中的数字ID
+func parseUintParam(c echo.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	return uint(id), err == nil
+}
+
+// editablePost 读取路径中的文章并检查当前用户的编辑权限
+// 失败时返回非零的状态码和错误信息
+func (h *PostHandler) editablePost(c echo.Context) (*model.Post, int, string) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return nil, http.StatusBadRequest, "Invalid post ID"
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, http.StatusUnauthorized, "Unauthorized"
+	}
+	post, err := h.postService.GetByID(id)
+	if err != nil {
+		return nil, http.StatusNotFound, "Post not found"
+	}
+	allowed, err := h.canModify(c, userID, post, model.PermPostEditOwn, model.PermPostEditAny)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err.Error()
+	}
+	if !allowed {
+		return nil, http.StatusForbidden, "Forbidden"
+	}
+	return post, 0, ""
+}
+
+// revisionError 版本不存在时返回 404，恢复时文章被并发修改返回 409
+func revisionError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Revision not found"})
+	case errors.Is(err, service.ErrPostVersionConflict):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrPostPreconditionRequired):
+		return c.JSON(http.StatusPreconditionRequired, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+// ListRevisions 列出文章的历史版本
+// GET /api/posts/:id/revisions
+func (h *PostHandler) ListRevisions(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	revisions, err := h.postService.ListRevisions(post.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, revisions)
+}
+
+// GetRevision 查看某个历史版本的完整内容
+// GET /api/posts/:id/revisions/:revision
+func (h *PostHandler) GetRevision(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	revisionID, ok := parseUintParam(c, "revision")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid revision ID"})
+	}
+	revision, err := h.postService.GetRevision(post.ID, revisionID)
+	if err != nil {
+		return revisionError(c, err)
+	}
+	return c.JSON(http.StatusOK, revision)
+}
+
+// DiffRevisions 比较两个历史版本
+// GET /api/posts/:id/revisions/diff?from=&to=
+func (h *PostHandler) DiffRevisions(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	from, err1 := strconv.ParseUint(c.QueryParam("from"), 10, 64)
+	to, err2 := strconv.ParseUint(c.QueryParam("to"), 10, 64)
+	if err1 != nil || err2 != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "from and to must be revision IDs"})
+	}
+	diff, err := h.postService.DiffRevisions(post.ID, uint(from), uint(to))
+	if err != nil {
+		return revisionError(c, err)
+	}
+	return c.JSON(http.StatusOK, diff)
+}
+
+type restoreRevisionRequest struct {
+	Version int `json:"version"`
+}
+
+// RestoreRevision 把文章恢复到某个历史版本
+// 与修改文章相同，需要通过 If-Match 头或 version 字段提供文章当前版本
+// POST /api/posts/:id/revisions/:revision/restore
+func (h *PostHandler) RestoreRevision(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	revisionID, ok := parseUintParam(c, "revision")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid revision ID"})
+	}
+	var req restoreRevisionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	if version, ok := ifMatchVersion(c); ok {
+		req.Version = version
+	}
+	restored, err := h.postService.RestoreRevision(auditActor(c), post.ID, revisionID, req.Version)
+	if err != nil {
+		return revisionError(c, err)
+	}
+	setPostETag(c, restored)
+	return c.JSON(http.StatusOK, restored)
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// postTrashError 文章不在回收站时返回 404
+func postTrashError(c echo.Context, err error) error {
+	if errors.Is(err, service.ErrPostNotInTrash) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// ListTrash 回收站列表
+// GET /api/admin/posts/trash?page=&page_size=
+func (h *PostHandler) ListTrash(c echo.Context) error {
+	page, pageSize := parsePagination(c, defaultPageSize)
+	posts, total, err := h.postService.ListTrash(page, pageSize)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, paginated(posts, total, page, pageSize))
+}
+
+// RestoreFromTrash 从回收站恢复文章
+// POST /api/admin/posts/trash/:id/restore
+func (h *PostHandler) RestoreFromTrash(c echo.Context) error {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
+	}
+	if err := h.postService.RestorePost(auditActor(c), id); err != nil {
+		return postTrashError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PurgeFromTrash 永久删除回收站中的文章
+// DELETE /api/admin/posts/trash/:id
+func (h *PostHandler) PurgeFromTrash(c echo.Context) error {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
+	}
+	if err := h.postService.PurgePost(auditActor(c), id); err != nil {
+		return postTrashError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// postUnlockHeader 不使用 Cookie 的客户端通过该请求头携带解锁令牌
+const postUnlockHeader = "X-Post-Token"
+
+type unlockPostRequest struct {
+	Password string `json:"password"`
+}
+
+// setPostPassword 请求中带有密码时计算哈希，留空表示不修改密码
+func setPostPassword(post *model.Post, password string) error {
+	if password == "" {
+		return nil
+	}
+	hash, err := service.HashPostPassword(password)
+	if err != nil {
+		return err
+	}
+	post.PasswordHash = hash
+	return nil
+}
+
+func postUnlockCookieName(postID uint) string {
+	return "post_unlock_" + strconv.FormatUint(uint64(postID), 10)
+}
+
+// postUnlockToken 读取文章的解锁令牌，请求头优先于 Cookie
+func postUnlockToken(c echo.Context, postID uint) string {
+	if token := c.Request().Header.Get(postUnlockHeader); token != "" {
+		return token
+	}
+	if cookie, err := c.Cookie(postUnlockCookieName(postID)); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// respondDetail 返回文章详情，受密码保护且未解锁的文章返回 401 和不含正文的详情
+func (h *PostHandler) respondDetail(c echo.Context, post *model.Post) error {
+	detail := h.postDetail(post)
+	if !h.unlockService.CanView(post, postUnlockToken(c, post.ID)) {
+		detail.Content = ""
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+			"error":     "Password required",
+			"protected": true,
+			"post":      detail,
+		})
+	}
+	return c.JSON(http.StatusOK, detail)
+}
+
+// Unlock 输入密码解锁受保护的文章，成功后设置只对该文章有效的 Cookie，同时在响应中返回令牌
+// POST /api/posts/:id/unlock
+func (h *PostHandler) Unlock(c echo.Context) error {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post ID"})
+	}
+	var req unlockPostRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	post, err := h.postService.GetPublishedByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Post not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	token, expiresAt, err := h.unlockService.Unlock(post, req.Password, c.RealIP())
+	if err != nil {
+		var throttled *service.PostUnlockThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(throttled.RetryAfter.Seconds())+1))
+			return c.JSON(http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+		case errors.Is(err, service.ErrWrongPostPassword):
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		case errors.Is(err, service.ErrPostNotProtected):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     postUnlockCookieName(post.ID),
+		Value:    token,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/api/posts",
+		MaxAge:   int(time.Until(expiresAt).Seconds()),
+	})
+	return c.JSON(http.StatusOK, map[string]interface{}{"token": token, "expires_at": expiresAt})
+}
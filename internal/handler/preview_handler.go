@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// CreatePreviewLink 为文章的某个版本生成无需登录即可访问的预览链接
+// POST /api/posts/:id/preview-links
+func (h *PostHandler) CreatePreviewLink(c echo.Context) error {
+	post, status, msg := h.editablePost(c)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	var req model.CreatePreviewLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if req.ExpiresIn < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "expires_in must not be negative"})
+	}
+	link, err := h.previewService.CreateLink(auditActor(c), post.ID, req.RevisionID, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPreviewTTLTooLong):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Revision not found"})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	return c.JSON(http.StatusCreated, link)
+}
+
+// Preview 通过预览链接查看文章的指定版本，不论文章是否已发布
+// GET /api/preview/:token
+func (h *PostHandler) Preview(c echo.Context) error {
+	post, revision, err := h.previewService.Resolve(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPreviewToken) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	post.Title = revision.Title
+	post.Content = revision.Content
+	post.Excerpt = revision.Excerpt
+	post.Tags = revision.Tags
+	post.MetaTitle = revision.MetaTitle
+	post.MetaDescription = revision.MetaDescription
+
+	// 预览内容不应被缓存或被搜索引擎收录
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("X-Robots-Tag", "noindex, nofollow")
+	return c.JSON(http.StatusOK, h.postDetail(post))
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ProfileHandler struct {
+	profileService *service.ProfileService
+}
+
+func NewProfileHandler(profileService *service.ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+	}
+}
+
+func (h *ProfileHandler) GetMe(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	profile, err := h.profileService.GetProfile(userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+	return c.JSON(http.StatusOK, profile)
+}
+
+func (h *ProfileHandler) UpdateMe(c echo.Context) error {
+	if _, ok := currentUserID(c); !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	var req model.UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	profile, err := h.profileService.UpdateProfile(auditActor(c), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserExists):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidNickname),
+			errors.Is(err, service.ErrInvalidAvatar),
+			errors.Is(err, service.ErrBioTooLong),
+			errors.Is(err, service.ErrInvalidEmail):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, profile)
+}
+
+type confirmEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ConfirmEmail 确认修改后的新邮箱，链接可能在未登录的设备上打开，因此不要求登录
+func (h *ProfileHandler) ConfirmEmail(c echo.Context) error {
+	req := new(confirmEmailRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	if err := h.profileService.ConfirmEmailChange(auditActor(c), req.Token); err != nil {
+		if errors.Is(err, service.ErrUserExists) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "邮箱修改成功"})
+}
+
+// GetAuthor 作者公开主页
+func (h *ProfileHandler) GetAuthor(c echo.Context) error {
+	user, posts, err := h.profileService.GetAuthor(c.Param("username"))
+	if err != nil {
+		if errors.Is(err, service.ErrAuthorNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	author := &model.AuthorProfile{
+		Username: user.Username,
+		Nickname: user.Nickname,
+		Avatar:   user.Avatar,
+		Bio:      user.Bio,
+		JoinedAt: user.CreatedAt,
+		Posts:    make([]*model.PostFrontend, 0, len(posts)),
+	}
+	for _, post := range posts {
+		date := post.CreatedAt
+		if post.PublishedAt != nil {
+			date = *post.PublishedAt
+		}
+		author.Posts = append(author.Posts, &model.PostFrontend{
+			ID:        post.ID,
+			Title:     post.Title,
+			Tags:      post.Tags,
+			Date:      date.Format("2006-01-02"),
+			Excerpt:   post.Excerpt,
+			Views:     post.Views,
+			Likes:     post.Likes,
+			Thumbnail: post.Thumbnail,
+			Protected: post.Status == model.Protected,
+		})
+	}
+	return c.JSON(http.StatusOK, author)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type RegistrationHandler struct {
+	registrationService *service.RegistrationService
+}
+
+func NewRegistrationHandler(registrationService *service.RegistrationService) *RegistrationHandler {
+	return &RegistrationHandler{
+		registrationService: registrationService,
+	}
+}
+
+func (h *RegistrationHandler) Register(c echo.Context) error {
+	var req model.RegisterRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	user, err := h.registrationService.Register(&req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRegistrationDisabled):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+		case errors.Is(err, service.ErrUserExists):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "注册成功，请查收验证邮件",
+		"id":      user.ID,
+	})
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (h *RegistrationHandler) VerifyEmail(c echo.Context) error {
+	req := new(verifyEmailRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	if err := h.registrationService.VerifyEmail(req.Token); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "邮箱验证成功"})
+}
+
+type resendVerificationRequest struct {
+	Email string `json:"email" validate:"required"`
+}
+
+func (h *RegistrationHandler) ResendVerification(c echo.Context) error {
+	req := new(resendVerificationRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	if err := h.registrationService.ResendVerification(req.Email); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to send email"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "如果该邮箱已注册且未验证，验证邮件已发送"})
+}
+
+func (h *RegistrationHandler) CreateInvitation(c echo.Context) error {
+	if _, ok := currentUserID(c); !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	var req model.CreateInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	code, invitation, err := h.registrationService.CreateInvitation(auditActor(c), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownRole) || errors.Is(err, service.ErrInvalidEmail) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"code":       code,
+		"invitation": invitation,
+	})
+}
+
+func (h *RegistrationHandler) ListInvitations(c echo.Context) error {
+	invitations, err := h.registrationService.ListInvitations()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, invitations)
+}
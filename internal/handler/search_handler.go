@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type SearchHandler struct {
+	searchService *service.SearchService
+}
+
+func NewSearchHandler(searchService *service.SearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// Search 全文搜索已发布的文章
+// GET /api/posts/search?q=&category_id=&tag=&page=&page_size=
+func (h *SearchHandler) Search(c echo.Context) error {
+	page, pageSize := parsePagination(c, defaultPageSize)
+	q := &model.PostSearchQuery{
+		Text:   c.QueryParam("q"),
+		Tag:    c.QueryParam("tag"),
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	}
+	if v := c.QueryParam("category_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid category_id"})
+		}
+		q.CategoryID = &id
+	}
+	results, total, err := h.searchService.Search(q)
+	if err != nil {
+		if errors.Is(err, service.ErrEmptySearchQuery) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, paginated(results, total, page, pageSize))
+}
+
+// Suggest 搜索框输入提示
+// GET /api/posts/suggest?q=
+func (h *SearchHandler) Suggest(c echo.Context) error {
+	suggestions, err := h.searchService.Suggest(c.QueryParam("q"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, suggestions)
+}
+
+// Reindex 重建全部文章的检索索引
+// POST /api/admin/search/reindex
+func (h *SearchHandler) Reindex(c echo.Context) error {
+	count, err := h.searchService.ReindexAll()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]any{"error": err.Error(), "indexed": count})
+	}
+	return c.JSON(http.StatusOK, map[string]int{"indexed": count})
+}
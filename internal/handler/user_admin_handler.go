@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type UserAdminHandler struct {
+	userAdminService *service.UserAdminService
+}
+
+func NewUserAdminHandler(userAdminService *service.UserAdminService) *UserAdminHandler {
+	return &UserAdminHandler{
+		userAdminService: userAdminService,
+	}
+}
+
+// userAdminError 将服务层错误映射为对应的状态码
+func userAdminError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrUserExists):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCannotModifySelf):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidUsername),
+		errors.Is(err, service.ErrInvalidEmail),
+		errors.Is(err, service.ErrUnknownRole),
+		errors.As(err, new(*service.PasswordPolicyError)):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// List 分页查询用户
+// GET /api/admin/users?q=&role=&status=active|disabled|deleted&page=&page_size=
+func (h *UserAdminHandler) List(c echo.Context) error {
+	filter := model.UserFilter{
+		Query:  c.QueryParam("q"),
+		Role:   c.QueryParam("role"),
+		Status: c.QueryParam("status"),
+	}
+	page, pageSize := parsePagination(c, defaultPageSize)
+	users, total, err := h.userAdminService.List(filter, page, pageSize)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	items := make([]*model.AdminUser, 0, len(users))
+	for _, user := range users {
+		items = append(items, model.NewAdminUser(user))
+	}
+	return c.JSON(http.StatusOK, paginated(items, total, page, pageSize))
+}
+
+func (h *UserAdminHandler) Get(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+	user, err := h.userAdminService.Get(id)
+	if err != nil {
+		return userAdminError(c, err)
+	}
+	return c.JSON(http.StatusOK, model.NewAdminUser(user))
+}
+
+func (h *UserAdminHandler) Create(c echo.Context) error {
+	var req model.CreateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	user, err := h.userAdminService.Create(auditActor(c), &req)
+	if err != nil {
+		return userAdminError(c, err)
+	}
+	return c.JSON(http.StatusCreated, model.NewAdminUser(user))
+}
+
+// userAction 解析路径中的用户ID并执行操作，成功时返回 204
+func (h *UserAdminHandler) userAction(c echo.Context, action func(service.Actor, uuid.UUID) error) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+	if err := action(auditActor(c), id); err != nil {
+		return userAdminError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *UserAdminHandler) Disable(c echo.Context) error {
+	return h.userAction(c, h.userAdminService.Disable)
+}
+
+func (h *UserAdminHandler) Enable(c echo.Context) error {
+	return h.userAction(c, h.userAdminService.Enable)
+}
+
+// Unlock 解除用户因登录失败过多而触发的锁定
+func (h *UserAdminHandler) Unlock(c echo.Context) error {
+	return h.userAction(c, h.userAdminService.Unlock)
+}
+
+func (h *UserAdminHandler) Delete(c echo.Context) error {
+	return h.userAction(c, h.userAdminService.Delete)
+}
+
+func (h *UserAdminHandler) Restore(c echo.Context) error {
+	return h.userAction(c, h.userAdminService.Restore)
+}
+
+func (h *UserAdminHandler) ForcePasswordReset(c echo.Context) error {
+	return h.userAction(c, h.userAdminService.ForcePasswordReset)
+}
+
+func (h *UserAdminHandler) SetRole(c echo.Context) error {
+	var req model.UpdateUserRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	return h.userAction(c, func(actor service.Actor, id uuid.UUID) error {
+		return h.userAdminService.SetRole(actor, id, req.Role)
+	})
+}
@@ -1,24 +1,47 @@
 package handler
 
 import (
+	"crist-blog/internal/model"
 	"crist-blog/internal/service"
+	"errors"
 	"net/http"
+	"strconv"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type UserHandler struct {
-	authService *service.AuthService
-	userService *service.UserService
+	authService      *service.AuthService
+	userService      *service.UserService
+	twoFactorService *service.TwoFactorService
 }
 
-func NewUserHandler(authService *service.AuthService, userService *service.UserService) *UserHandler {
+func NewUserHandler(
+	authService *service.AuthService,
+	userService *service.UserService,
+	twoFactorService *service.TwoFactorService) *UserHandler {
 	return &UserHandler{
-		authService: authService,
-		userService: userService,
+		authService:      authService,
+		userService:      userService,
+		twoFactorService: twoFactorService,
 	}
 }
 
+// loginError 登录失败的统一响应，被限流时附带 Retry-After
+func loginError(c echo.Context, err error) error {
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(throttled.RetryAfter.Seconds())+1))
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+	}
+	if errors.Is(err, service.ErrAccountDisabled) || errors.Is(err, service.ErrPasswordResetRequired) ||
+		errors.Is(err, service.ErrEmailNotVerified) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+}
+
 type loginRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
@@ -29,31 +52,88 @@ func (h *UserHandler) Login(c echo.Context) error {
 	if err := c.Bind(req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
 	}
-	user, err := h.userService.Login(req.Username, req.Password)
+	user, err := h.userService.Login(auditActor(c), req.Username, req.Password)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		return loginError(c, err)
+	}
+
+	// 启用了两步验证时先返回中间令牌，验证码通过后才签发正式令牌
+	if user.TOTPEnabled {
+		challenge, err := h.twoFactorService.IssueChallenge(user)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate tokens"})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"mfa_required": true,
+			"mfa_token":    challenge,
+		})
 	}
 
+	return h.issueTokens(c, user)
+}
+
+type loginTwoFactorRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"` // 6 位验证码或恢复码
+}
+
+// LoginTwoFactor 两步登录的第二步：提交中间令牌和验证码换取正式令牌
+func (h *UserHandler) LoginTwoFactor(c echo.Context) error {
+	req := new(loginTwoFactorRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
+	}
+	// 验证码只有 6 位，失败次数按用户和 IP 计入 LoginGuard 防止穷举
+	user, err := h.twoFactorService.VerifyChallenge(req.MFAToken, req.Code, c.RealIP())
+	if err != nil {
+		return loginError(c, err)
+	}
+	return h.issueTokens(c, user)
+}
+
+// issueTokens 签发访问令牌和刷新令牌，刷新令牌写入 Cookie
+func (h *UserHandler) issueTokens(c echo.Context, user *model.User) error {
 	userAgent := c.Request().UserAgent()
 	ip := c.RealIP()
 
 	accessToken, refreshToken, err := h.authService.GenerateTokens(user, userAgent, ip)
+	if errors.Is(err, service.ErrAccountDisabled) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate tokens"})
 	}
 
+	setRefreshCookie(c, refreshToken)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"access_token": accessToken,
+	})
+}
+
+// setRefreshCookie 写入刷新令牌 Cookie，路径限定为刷新接口所在的 /api/auth
+func setRefreshCookie(c echo.Context, refreshToken string) {
 	c.SetCookie(&http.Cookie{
 		Name:     "refresh_token",
 		Value:    refreshToken,
 		HttpOnly: true,
 		Secure:   true,
 		SameSite: http.SameSiteStrictMode,
-		Path:     "/api/refresh",
-		MaxAge:   int(h.authService.GetTheRefreshTokenExpired()),
+		Path:     "/api/auth",
+		MaxAge:   int(service.RefreshTokenExpire.Seconds()),
 	})
+}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"access_token": accessToken,
+// clearRefreshCookie 让浏览器删除刷新令牌 Cookie
+func clearRefreshCookie(c echo.Context) {
+	c.SetCookie(&http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		Path:     "/api/auth",
+		MaxAge:   -1,
 	})
 }
 
@@ -63,12 +143,68 @@ func (h *UserHandler) Refresh(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Refresh token not found"})
 	}
 
-	accessToken, err := h.authService.RefreshAccessToken(cookie.Value)
+	accessToken, refreshToken, err := h.authService.RefreshAccessToken(cookie.Value, c.Request().UserAgent(), c.RealIP())
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
 	}
+	setRefreshCookie(c, refreshToken)
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"access_token": accessToken,
 	})
 }
+
+func (h *UserHandler) Logout(c echo.Context) error {
+	if _, ok := currentUserID(c); !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	jti, expiresAt := currentAccessToken(c)
+	if err := h.authService.Logout(auditActor(c), currentSessionID(c), jti, expiresAt); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	clearRefreshCookie(c)
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *UserHandler) ListSessions(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	sessions, err := h.authService.ListSessions(userID, currentSessionID(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, sessions)
+}
+
+func (h *UserHandler) RevokeSession(c echo.Context) error {
+	if _, ok := currentUserID(c); !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid session ID"})
+	}
+	if err := h.authService.RevokeSession(auditActor(c), sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *UserHandler) RevokeOtherSessions(c echo.Context) error {
+	if _, ok := currentUserID(c); !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	sessionID := currentSessionID(c)
+	if sessionID == uuid.Nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Current session unknown, please login again"})
+	}
+	if err := h.authService.RevokeOtherSessions(auditActor(c), sessionID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
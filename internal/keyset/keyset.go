@@ -0,0 +1,191 @@
+package keyset
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no signing key configured")
+	ErrUnknownKey   = errors.New("unknown key id")
+)
+
+// Key 是一把以 kid 标识的 JWT 密钥
+// Private 为空时表示仅用于验签（例如轮换后退役的旧公钥）
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	switch k.Algorithm {
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodRS256
+	}
+}
+
+// KeySet 持有当前所有可用密钥，签名时使用 signingKID 对应的私钥，
+// 验签时根据 JWT 头部的 kid 选择对应公钥，从而支持平滑轮换
+type KeySet struct {
+	mu         sync.RWMutex
+	keys       map[string]*Key
+	signingKID string
+}
+
+func New() *KeySet {
+	return &KeySet{keys: make(map[string]*Key)}
+}
+
+// Add 加入一把密钥，若 kid 已存在则覆盖
+func (ks *KeySet) Add(key *Key) error {
+	if key.ID == "" {
+		return errors.New("key id is required")
+	}
+	if key.Public == nil && key.Private != nil {
+		key.Public = key.Private.Public()
+	}
+	alg, err := algorithmFor(key.Public)
+	if err != nil {
+		return fmt.Errorf("key %s: %w", key.ID, err)
+	}
+	key.Algorithm = alg
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[key.ID] = key
+	return nil
+}
+
+// SetSigningKey 指定用于签名的 kid，该密钥必须包含私钥
+func (ks *KeySet) SetSigningKey(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	key, ok := ks.keys[kid]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	if key.Private == nil {
+		return fmt.Errorf("key %s has no private key", kid)
+	}
+	ks.signingKID = kid
+	return nil
+}
+
+// SigningKey 返回当前签名密钥
+func (ks *KeySet) SigningKey() (*Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[ks.signingKID]
+	if !ok {
+		return nil, ErrNoSigningKey
+	}
+	return key, nil
+}
+
+// Sign 使用当前签名密钥签发 JWT，并在头部写入 kid
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key, err := ks.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc 供 jwt.Parse 使用，根据 kid 查找验签公钥并校验算法是否匹配
+func (ks *KeySet) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("missing kid header")
+	}
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	ks.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	if t.Method.Alg() != key.Algorithm {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public, nil
+}
+
+// ValidMethods 返回允许的签名算法，传给 jwt.WithValidMethods 防止算法混淆
+func (ks *KeySet) ValidMethods() []string {
+	return []string{AlgRS256, AlgEdDSA}
+}
+
+// JWK 是 RFC 7517 中单个公钥的 JSON 表示
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 导出全部公钥，供其它服务验证本服务签发的令牌
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Algorithm}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func algorithmFor(pub crypto.PublicKey) (string, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return AlgRS256, nil
+	case ed25519.PublicKey:
+		return AlgEdDSA, nil
+	case *ecdsa.PublicKey:
+		return "", errors.New("ECDSA keys are not supported")
+	default:
+		return "", errors.New("unsupported key type")
+	}
+}
@@ -0,0 +1,207 @@
+package keyset
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config 描述密钥的来源
+type Config struct {
+	// Dir 密钥目录，<kid>.pem 为私钥，<kid>.pub.pem 为仅验签的公钥
+	Dir string
+	// SigningKID 指定签名使用的 kid，为空时取 kid 字典序最大的私钥
+	SigningKID string
+	// PrivateKeyPEM 直接通过环境变量传入的私钥，kid 由 PrivateKeyID 指定
+	PrivateKeyPEM string
+	PrivateKeyID  string
+	// Algorithm 在没有任何密钥时自动生成密钥所用的算法（RS256 / EdDSA）
+	Algorithm string
+}
+
+// Load 按配置加载密钥
+// 若未找到任何私钥：配置了 Dir 时生成新密钥并写入目录以便重启后继续使用，
+// 否则生成仅存在于内存中的临时密钥（重启后所有令牌失效）
+func Load(cfg Config) (*KeySet, error) {
+	ks := New()
+
+	if cfg.Dir != "" {
+		if err := loadDir(ks, cfg.Dir); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.PrivateKeyPEM != "" {
+		kid := cfg.PrivateKeyID
+		if kid == "" {
+			kid = "env"
+		}
+		key, err := parsePEM(kid, []byte(cfg.PrivateKeyPEM))
+		if err != nil {
+			return nil, err
+		}
+		if err := ks.Add(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if !ks.hasPrivateKey() {
+		key, err := Generate(cfg.Algorithm, time.Now().UTC().Format("20060102T150405Z"))
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Dir != "" {
+			if err := writePrivateKey(cfg.Dir, key); err != nil {
+				return nil, err
+			}
+			log.Printf("🔑 Generated new %s signing key %s in %s", key.Algorithm, key.ID, cfg.Dir)
+		} else {
+			log.Printf("⚠️  No JWT signing key configured, using an ephemeral %s key; tokens will not survive a restart", key.Algorithm)
+		}
+		if err := ks.Add(key); err != nil {
+			return nil, err
+		}
+	}
+
+	signingKID := cfg.SigningKID
+	if signingKID == "" {
+		signingKID = ks.latestPrivateKID()
+	}
+	if err := ks.SetSigningKey(signingKID); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Generate 生成一把新的签名密钥
+func Generate(algorithm, kid string) (*Key, error) {
+	var signer crypto.Signer
+	switch algorithm {
+	case "", AlgEdDSA:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		signer = priv
+	case AlgRS256:
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		signer = priv
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+	key := &Key{ID: kid, Private: signer, Public: signer.Public()}
+	key.Algorithm, _ = algorithmFor(key.Public)
+	return key, nil
+}
+
+func loadDir(ks *KeySet, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return os.MkdirAll(dir, 0o700)
+		}
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".pem") {
+			continue
+		}
+		kid := strings.TrimSuffix(strings.TrimSuffix(name, ".pem"), ".pub")
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		key, err := parsePEM(kid, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		// 同一 kid 既有私钥又有公钥文件时以私钥为准
+		if existing, ok := ks.keys[kid]; ok && existing.Private != nil && key.Private == nil {
+			continue
+		}
+		if err := ks.Add(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parsePEM(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("private key cannot sign")
+		}
+		return &Key{ID: kid, Private: signer, Public: signer.Public()}, nil
+	case "RSA PRIVATE KEY":
+		priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{ID: kid, Private: priv, Public: priv.Public()}, nil
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{ID: kid, Public: pub}, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+func writePrivateKey(dir string, key *Key) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return os.WriteFile(filepath.Join(dir, key.ID+".pem"), data, 0o600)
+}
+
+func (ks *KeySet) hasPrivateKey() bool {
+	return ks.latestPrivateKID() != ""
+}
+
+func (ks *KeySet) latestPrivateKID() string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	var kids []string
+	for kid, key := range ks.keys {
+		if key.Private != nil {
+			kids = append(kids, kid)
+		}
+	}
+	if len(kids) == 0 {
+		return ""
+	}
+	sort.Strings(kids)
+	return kids[len(kids)-1]
+}
@@ -0,0 +1,13 @@
+package mailer
+
+// Message 是一封纯文本邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 发送邮件，生产环境使用 SMTPMailer，本地开发使用 LogMailer
+type Mailer interface {
+	Send(msg Message) error
+}
@@ -23,12 +23,19 @@ func AuthMiddleware(authService *service.AuthService) echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
 			}
 			tokenStr := patrs[1]
-			token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-				if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, errors.New("unexpected signing method")
+			// 个人访问令牌：权限受令牌 scopes 限制，由 RequirePermission 检查
+			if strings.HasPrefix(tokenStr, service.PersonalAccessTokenPrefix) {
+				pat, err := authService.AuthenticatePersonalAccessToken(tokenStr)
+				if err != nil {
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
 				}
-				return []byte(authService.JwtSecret()), nil
-			})
+				c.Set("user_id", pat.UserID)
+				c.Set("auth_method", "pat")
+				c.Set("token_scopes", []string(pat.Scopes))
+				return next(c)
+			}
+			keys := authService.Keys()
+			token, err := jwt.Parse(tokenStr, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods()))
 			if err != nil {
 				if errors.Is(err, jwt.ErrTokenExpired) {
 					return c.JSON(http.StatusUnauthorized, map[string]string{
@@ -49,6 +56,10 @@ func AuthMiddleware(authService *service.AuthService) echo.MiddlewareFunc {
 			if !ok {
 				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
 			}
+			// 只接受访问令牌，两步登录的中间令牌等同样由本服务签发，但不能用于访问接口
+			if calims["typ"] != "access" {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+			}
 			userIDStr, ok := calims["user_id"].(string)
 			if !ok {
 				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
@@ -57,9 +68,40 @@ func AuthMiddleware(authService *service.AuthService) echo.MiddlewareFunc {
 			if err != nil {
 				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
 			}
+			jti, _ := calims["jti"].(string)
+			if jti != "" && authService.IsAccessTokenRevoked(jti) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "access token revoked",
+				})
+			}
+			// 账号停用或删除后，尚未过期的访问令牌也立即失效
+			if !authService.IsUserActive(userID) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": service.ErrAccountDisabled.Error()})
+			}
 			c.Set("user_id", userID)
+			c.Set("auth_method", "jwt")
+			c.Set("jti", jti)
+			if exp, err := calims.GetExpirationTime(); err == nil && exp != nil {
+				c.Set("token_expires_at", exp.Time)
+			}
+			// sid 标识当前会话（刷新令牌族），早期签发的令牌可能没有该声明
+			if sid, ok := calims["sid"].(string); ok {
+				if sessionID, err := uuid.Parse(sid); err == nil {
+					c.Set("session_id", sessionID)
+				}
+			}
 
 			return next(c)
 		}
 	}
 }
+
+// RejectPersonalAccessToken 拒绝使用个人访问令牌访问，用于令牌管理、两步验证等账号安全相关接口
+func RejectPersonalAccessToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get("auth_method") == "pat" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Personal access tokens are not allowed here"})
+		}
+		return next(c)
+	}
+}
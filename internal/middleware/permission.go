@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crist-blog/internal/service"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// RequirePermission 要求当前用户拥有全部指定权限，需放在 AuthMiddleware 之后
+func RequirePermission(rbacService *service.RBACService, permissions ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, ok := c.Get("user_id").(uuid.UUID)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+			}
+			// 使用个人访问令牌时，权限还必须在令牌的 scopes 之内
+			scopes, scoped := c.Get("token_scopes").([]string)
+			for _, permission := range permissions {
+				if scoped && !slices.Contains(scopes, permission) {
+					return c.JSON(http.StatusForbidden, map[string]string{"error": "Token scope does not allow this action"})
+				}
+				allowed, err := rbacService.UserHasPermission(userID, permission)
+				if err != nil {
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+				}
+				if !allowed {
+					return c.JSON(http.StatusForbidden, map[string]string{"error": "Forbidden"})
+				}
+			}
+			return next(c)
+		}
+	}
+}
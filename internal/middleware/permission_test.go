@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"crist-blog/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func runMiddleware(mw echo.MiddlewareFunc, values map[string]interface{}) (*httptest.ResponseRecorder, bool) {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	for key, value := range values {
+		c.Set(key, value)
+	}
+	called := false
+	_ = mw(func(c echo.Context) error {
+		called = true
+		return c.NoContent(http.StatusNoContent)
+	})(c)
+	return rec, called
+}
+
+func TestRequirePermissionRejectsScopeOutsidePersonalAccessToken(t *testing.T) {
+	// 令牌范围检查发生在查询角色权限之前，超出范围时不会访问 RBACService
+	rec, called := runMiddleware(RequirePermission(nil, model.PermPostPublish), map[string]interface{}{
+		"user_id":      uuid.New(),
+		"auth_method":  "pat",
+		"token_scopes": []string{model.PermPostCreate},
+	})
+	if called || rec.Code != http.StatusForbidden {
+		t.Fatalf("got status %d, handler called %v; want 403 without calling the handler", rec.Code, called)
+	}
+}
+
+func TestRequirePermissionRequiresUser(t *testing.T) {
+	rec, called := runMiddleware(RequirePermission(nil, model.PermPostCreate), nil)
+	if called || rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, handler called %v; want 401", rec.Code, called)
+	}
+}
+
+func TestRejectPersonalAccessToken(t *testing.T) {
+	mw := echo.MiddlewareFunc(RejectPersonalAccessToken)
+	rec, called := runMiddleware(mw, map[string]interface{}{"auth_method": "pat"})
+	if called || rec.Code != http.StatusForbidden {
+		t.Fatalf("pat: got status %d, handler called %v; want 403", rec.Code, called)
+	}
+	if _, called := runMiddleware(mw, map[string]interface{}{"auth_method": "jwt"}); !called {
+		t.Fatal("jwt: handler should be called")
+	}
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// 用户状态，用于管理后台筛选
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+	UserStatusDeleted  = "deleted"
+)
+
+// UserFilter 管理后台用户查询条件
+type UserFilter struct {
+	Query  string // 按用户名、邮箱、昵称模糊搜索
+	Role   string
+	Status string // 为空时返回所有未删除的用户
+}
+
+// AdminUser 管理后台展示的用户信息，比普通接口多出删除时间
+type AdminUser struct {
+	*User
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// NewAdminUser 将用户转换为管理后台的展示结构
+func NewAdminUser(user *User) *AdminUser {
+	view := &AdminUser{User: user}
+	if user.DeletedAt.Valid {
+		view.DeletedAt = &user.DeletedAt.Time
+	}
+	return view
+}
+
+// CreateUserRequest 管理员创建用户请求结构体
+type CreateUserRequest struct {
+	Username string `json:"username" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password"` // 为空时向用户邮箱发送设置密码的链接
+	Nickname string `json:"nickname"`
+	Role     string `json:"role"` // 为空时为 reader
+}
+
+// UpdateUserRoleRequest 修改用户角色请求结构体
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 审计结果
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// 审计动作
+const (
+	AuditLogin                = "auth.login"
+	AuditRefresh              = "auth.refresh"
+	AuditRefreshReuse         = "auth.refresh_reuse"
+	AuditLogout               = "auth.logout"
+	AuditSessionRevoke        = "session.revoke"
+	AuditSessionRevokeOthers  = "session.revoke_others"
+	AuditUserUnlock           = "user.unlock"
+	AuditProfileUpdate        = "user.profile_update"
+	AuditEmailChange          = "user.email_change"
+	AuditUserCreate           = "user.create"
+	AuditUserDisable          = "user.disable"
+	AuditUserEnable           = "user.enable"
+	AuditUserDelete           = "user.delete"
+	AuditUserRestore          = "user.restore"
+	AuditUserForceReset       = "user.force_password_reset"
+	AuditUserRoleChange       = "user.role_change"
+	AuditPasswordChange       = "user.password_change"
+	AuditPasswordForgot       = "user.password_forgot"
+	AuditPasswordReset        = "user.password_reset"
+	AuditTwoFactorEnable      = "user.2fa_enable"
+	AuditTwoFactorDisable     = "user.2fa_disable"
+	AuditRecoveryCodesRegen   = "user.2fa_recovery_codes"
+	AuditTwoFactorReset       = "user.2fa_reset"
+	AuditTokenCreate          = "token.create"
+	AuditTokenRevoke          = "token.revoke"
+	AuditIdentityLink         = "identity.link"
+	AuditIdentityUnlink       = "identity.unlink"
+	AuditInvitationCreate     = "invitation.create"
+	AuditPostCreate           = "post.create"
+	AuditPostUpdate           = "post.update"
+	AuditPostDelete           = "post.delete"
+	AuditPostRevisionRestore  = "post.revision_restore"
+	AuditPostScheduledPublish = "post.scheduled_publish"
+	AuditPostPreviewLink      = "post.preview_link"
+	AuditPostRestore          = "post.restore"
+	AuditPostPurge            = "post.purge"
+	AuditCategoryCreate       = "category.create"
+	AuditCategoryUpdate       = "category.update"
+	AuditCategoryDelete       = "category.delete"
+)
+
+// AuditLog represents the 'audit_logs' table.
+// 只追加的安全审计记录，数据库触发器禁止修改和删除
+type AuditLog struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ActorID    *uuid.UUID `gorm:"type:uuid;index" json:"actor_id,omitempty"` // 匿名操作（如登录失败）为空
+	ActorName  string     `gorm:"type:text" json:"actor_name,omitempty"`     // 登录时提交的用户名
+	Action     string     `gorm:"type:text;not null;index" json:"action"`
+	TargetType string     `gorm:"type:text" json:"target_type,omitempty"`
+	TargetID   string     `gorm:"type:text" json:"target_id,omitempty"`
+	IPAddress  string     `gorm:"type:text" json:"ip_address"`
+	UserAgent  string     `gorm:"type:text" json:"user_agent"`
+	Outcome    string     `gorm:"type:text;not null" json:"outcome"`
+	Detail     string     `gorm:"type:text" json:"detail,omitempty"` // 失败原因等补充信息
+	CreatedAt  time.Time  `gorm:"type:timestamptz;default:now();index" json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "admin.audit_logs"
+}
+
+// AuditLogFilter 审计日志查询条件，零值字段不参与过滤
+type AuditLogFilter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Outcome    string
+	IPAddress  string
+	From       *time.Time
+	To         *time.Time
+}
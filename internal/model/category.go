@@ -19,6 +19,13 @@ type CreatePostCategory struct {
 	Name string    `json:"name"`
 }
 
+// CategoryRequest 创建/更新分类请求结构体
+type CategoryRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Slug        string `json:"slug" validate:"required"`
+	Description string `json:"description"`
+}
+
 func (C *Category) TableName() string {
 	return "blog.categories"
 }
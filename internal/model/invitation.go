@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation represents the 'invitations' table.
+// 邀请码只保存哈希，明文仅在创建时返回一次
+type Invitation struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	CodeHash  string     `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	Role      string     `gorm:"type:text;not null" json:"role"`
+	Email     string     `gorm:"type:text" json:"email,omitempty"` // 非空时只有该邮箱可以使用
+	CreatedBy uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	ExpiresAt time.Time  `gorm:"type:timestamptz;not null" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"type:timestamptz" json:"used_at,omitempty"`
+	UsedBy    *uuid.UUID `gorm:"type:uuid" json:"used_by,omitempty"`
+	CreatedAt time.Time  `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+func (Invitation) TableName() string {
+	return "admin.invitations"
+}
+
+// CreateInvitationRequest 创建邀请码请求结构体
+type CreateInvitationRequest struct {
+	Role      string `json:"role" validate:"required"`
+	Email     string `json:"email"`
+	ExpiresIn int    `json:"expires_in"` // 有效期（小时），为 0 时使用默认值
+}
+
+// RegisterRequest 注册请求结构体
+type RegisterRequest struct {
+	Username   string `json:"username" validate:"required"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required"`
+	Nickname   string `json:"nickname"`
+	InviteCode string `json:"invite_code"`
+}
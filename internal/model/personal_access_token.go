@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PersonalAccessToken represents the 'personal_access_tokens' table.
+// 供脚本和 CI 使用的长期令牌，只保存哈希，Scopes 限定可使用的权限
+type PersonalAccessToken struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name        string         `gorm:"type:text;not null" json:"name"`
+	TokenPrefix string         `gorm:"type:text;not null" json:"token_prefix"` // 令牌开头几位，便于用户辨认
+	TokenHash   string         `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	Scopes      pq.StringArray `gorm:"type:text[]" json:"scopes"`
+	LastUsedAt  *time.Time     `gorm:"type:timestamptz" json:"last_used_at,omitempty"`
+	ExpiresAt   *time.Time     `gorm:"type:timestamptz" json:"expires_at,omitempty"` // 为空表示永不过期
+	RevokedAt   *time.Time     `gorm:"type:timestamptz" json:"revoked_at,omitempty"`
+	CreatedAt   time.Time      `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+func (PersonalAccessToken) TableName() string {
+	return "admin.personal_access_tokens"
+}
+
+// CreatePersonalAccessTokenRequest 创建个人访问令牌请求结构体
+type CreatePersonalAccessTokenRequest struct {
+	Name      string   `json:"name" validate:"required"`
+	Scopes    []string `json:"scopes" validate:"required"`
+	ExpiresIn int      `json:"expires_in"` // 有效期（天），为 0 时永不过期
+}
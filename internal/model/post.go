@@ -14,6 +14,10 @@ const (
 	Draft     PostStatus = "draft"
 	Published PostStatus = "published"
 	Private   PostStatus = "private"
+	// Scheduled 定时发布，到达 PublishedAt 后由后台任务改为 Published
+	Scheduled PostStatus = "scheduled"
+	// Protected 出现在文章列表中，但需要输入密码才能查看正文
+	Protected PostStatus = "protected"
 )
 
 type Post struct {
@@ -32,7 +36,11 @@ type Post struct {
 	PublishedAt     *time.Time     `json:"published_at"`
 	MetaTitle       string         `gorm:"type:text" json:"meta_title"`
 	MetaDescription string         `gorm:"type:text" json:"meta_description"`
-	SearchVector    interface{}    `gorm:"type:tsvector" json:"-"`
+	PasswordHash    string         `gorm:"type:text" json:"-"`                       // 受密码保护文章的 bcrypt 哈希
+	Version         int            `gorm:"not null;default:1" json:"version"`        // 每次保存加一，用于检测并发修改
+	SearchVector    interface{}    `gorm:"type:tsvector;->:false;<-:false" json:"-"` // 由 SearchService 维护，GORM 不读写
+	SearchPinyin    string         `gorm:"type:text;->:false;<-:false" json:"-"`     // 标题和标签的拼音，用于输入提示
+	SearchDocument  string         `gorm:"type:text;->:false;<-:false" json:"-"`     // 分词后的正文，用于生成搜索结果片段
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
@@ -40,18 +48,19 @@ type Post struct {
 
 // CreatePostRequest 创建文章请求结构体
 type CreatePostRequest struct {
-	UserID          string     `json:"user_id" validate:"required,uuid4"`
 	Title           string     `json:"title" validate:"required"`
-	Slug            string     `json:"slug" validate:"required"`
+	Slug            string     `json:"slug"` // 为空时根据标题自动生成
 	Content         string     `json:"content"`
 	Excerpt         string     `json:"excerpt"`
-	Status          string     `json:"status" validate:"oneof=draft published private"`
+	Status          string     `json:"status" validate:"oneof=draft published private scheduled protected"`
 	CategoryID      string     `json:"category_id" validate:"required,uuid4"`
 	Tags            []string   `json:"tags"`
 	MetaTitle       string     `json:"meta_title"`
 	PublishedAt     *time.Time `json:"published_at"`
 	MetaDescription string     `json:"meta_description"`
 	Thumbnail       string     `json:"thumbnail"`
+	Password        string     `json:"password"` // 访问密码，仅 protected 状态使用，为空时保留原密码
+	Version         int        `json:"version"`  // 修改时必须提供期望的当前版本（或通过 If-Match 头传递），缺少时返回 428
 }
 
 type PostFrontend struct {
@@ -63,36 +72,41 @@ type PostFrontend struct {
 	Views     int      `gorm:"default:0" json:"views"`
 	Likes     int      `gorm:"default:0" json:"likes"`
 	Thumbnail string   `json:"thumbnail,omitempty"`
+	Protected bool     `json:"protected,omitempty"` // 需要密码才能查看正文
 }
 
 type HotPost struct {
-	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Title      string    `gorm:"type:text;not null" json:"title"`
-	CategoryID uuid.UUID `gorm:"type:uuid;not null" json:"category_id"`
-	CreatedAt  time.Time `json:"created_at"`
-	Excerpt    string    `gorm:"type:text" json:"excerpt"`
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Title      string     `gorm:"type:text;not null" json:"title"`
+	Status     PostStatus `json:"status"`
+	CategoryID uuid.UUID  `gorm:"type:uuid;not null" json:"category_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Excerpt    string     `gorm:"type:text" json:"excerpt"`
 }
 
 type HotPostFrontend struct {
-	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
-	Title    string `gorm:"type:text;not null" json:"title"`
-	Category string `json:"category"`
-	Date     string `json:"date"`
-	Excerpt  string `gorm:"type:text" json:"excerpt"`
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Title     string `gorm:"type:text;not null" json:"title"`
+	Category  string `json:"category"`
+	Date      string `json:"date"`
+	Excerpt   string `gorm:"type:text" json:"excerpt"`
+	Protected bool   `json:"protected,omitempty"`
 }
 
 type LatestPost struct {
-	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Title      string    `gorm:"type:text;not null" json:"title"`
-	CategoryID uuid.UUID `gorm:"type:uuid;not null" json:"category_id"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Title      string     `gorm:"type:text;not null" json:"title"`
+	Status     PostStatus `json:"status"`
+	CategoryID uuid.UUID  `gorm:"type:uuid;not null" json:"category_id"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
 
 type LatestPostFrontend struct {
-	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
-	Title    string `gorm:"type:text;not null" json:"title"`
-	Date     string `json:"date"`
-	Category string `json:"category"`
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Title     string `gorm:"type:text;not null" json:"title"`
+	Date      string `json:"date"`
+	Category  string `json:"category"`
+	Protected bool   `json:"protected,omitempty"`
 }
 
 func (Post) TableName() string {
@@ -103,6 +117,7 @@ func (Post) TableName() string {
 type PostDetail struct {
 	ID              uint     `json:"id"`
 	Title           string   `json:"title"`
+	Slug            string   `json:"slug"`
 	Content         string   `json:"content"` // Markdown 原文
 	Date            string   `json:"date"`    // 格式化后的发布日期，如 "2025年12月15日"
 	Tags            []string `json:"tags"`
@@ -0,0 +1,49 @@
+package model
+
+// 批量操作类型
+const (
+	BulkSetStatus   = "set_status"
+	BulkSetCategory = "set_category"
+	BulkAddTags     = "add_tags"
+	BulkRemoveTags  = "remove_tags"
+	BulkDelete      = "delete"
+	BulkRestore     = "restore"
+)
+
+// BulkPostFilter 按条件选择文章，字段含义与文章列表的查询参数相同
+type BulkPostFilter struct {
+	CategoryID string `json:"category_id"`
+	Tag        string `json:"tag"`
+	AuthorID   string `json:"author_id"`
+	Status     string `json:"status"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+// BulkPostRequest 批量操作请求结构体，IDs 和 Filter 二选一
+// restore 操作的 Filter 在回收站中匹配
+type BulkPostRequest struct {
+	Action     string          `json:"action"`
+	IDs        []uint          `json:"ids"`
+	Filter     *BulkPostFilter `json:"filter"`
+	Status     string          `json:"status"`      // set_status
+	CategoryID string          `json:"category_id"` // set_category
+	Tags       []string        `json:"tags"`        // add_tags、remove_tags
+	// Atomic 为 true 时任意一篇文章失败则全部不修改
+	Atomic bool `json:"atomic"`
+}
+
+// BulkItemResult 单篇文章的操作结果
+type BulkItemResult struct {
+	ID    uint   `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// BulkPostResult 批量操作结果，Items 的顺序与请求的文章顺序一致
+type BulkPostResult struct {
+	Action    string            `json:"action"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Items     []*BulkItemResult `json:"items"`
+}
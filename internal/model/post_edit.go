@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostLock represents the 'post_locks' table.
+// 文章的编辑锁，只用于提示其他编辑者，保存文章时不检查
+// 持有者需要定期续期，过期的锁可以被任何人获取
+type PostLock struct {
+	PostID     uint      `gorm:"primaryKey;autoIncrement:false" json:"post_id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	Username   string    `gorm:"->;-:migration" json:"username"` // 只读，查询时关联 users 表
+	AcquiredAt time.Time `gorm:"type:timestamptz;not null" json:"acquired_at"`
+	ExpiresAt  time.Time `gorm:"type:timestamptz;not null" json:"expires_at"`
+}
+
+func (PostLock) TableName() string {
+	return "blog.post_locks"
+}
+
+// PostAutosave represents the 'post_autosaves' table.
+// 每个用户对每篇文章保留一份自动保存的草稿，不影响文章本身
+// BaseVersion 为草稿开始编辑时文章的版本，用于提示文章在此期间是否被他人修改
+type PostAutosave struct {
+	PostID          uint           `gorm:"primaryKey;autoIncrement:false" json:"post_id"`
+	UserID          uuid.UUID      `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Title           string         `gorm:"type:text" json:"title"`
+	Content         string         `gorm:"type:text" json:"content"`
+	Excerpt         string         `gorm:"type:text" json:"excerpt"`
+	Tags            pq.StringArray `gorm:"type:text[]" json:"tags"`
+	MetaTitle       string         `gorm:"type:text" json:"meta_title"`
+	MetaDescription string         `gorm:"type:text" json:"meta_description"`
+	BaseVersion     int            `gorm:"not null" json:"base_version"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+func (PostAutosave) TableName() string {
+	return "blog.post_autosaves"
+}
+
+// AutosaveRequest 自动保存请求结构体
+type AutosaveRequest struct {
+	Title           string   `json:"title"`
+	Content         string   `json:"content"`
+	Excerpt         string   `json:"excerpt"`
+	Tags            []string `json:"tags"`
+	MetaTitle       string   `json:"meta_title"`
+	MetaDescription string   `json:"meta_description"`
+	BaseVersion     int      `json:"base_version"`
+}
+
+// AcquireLockRequest 获取编辑锁请求结构体
+type AcquireLockRequest struct {
+	// Takeover 为 true 时强制接管其他人持有的锁
+	Takeover bool `json:"takeover"`
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// 文章列表的排序字段
+const (
+	PostSortPublishedAt = "published_at"
+	PostSortViews       = "views"
+	PostSortLikes       = "likes"
+)
+
+// PostQuery 文章列表查询条件，零值字段不参与过滤
+type PostQuery struct {
+	CategoryID *uuid.UUID
+	Tag        string
+	AuthorID   *uuid.UUID
+	Status     PostStatus
+	Listed     bool       // 只返回出现在公开列表中的文章（已发布和受密码保护）
+	From       *time.Time // 发布时间下限（含），未发布的文章按创建时间
+	To         *time.Time // 发布时间上限（不含）
+	Sort       string     // PostSort* 之一，默认 published_at
+	Ascending  bool
+
+	Limit  int
+	Offset int
+	// After 不为空时使用游标分页，忽略 Offset；值的类型与排序字段一致
+	After *PostCursor
+}
+
+// PostCursor 游标分页的位置，即上一页最后一篇文章的排序值和ID
+type PostCursor struct {
+	Sort  string      `json:"s"`
+	Value interface{} `json:"v"`
+	ID    uint        `json:"id"`
+}
+
+// PostSummary 文章列表项，不包含正文
+type PostSummary struct {
+	ID          uint           `json:"id"`
+	UserID      uuid.UUID      `json:"user_id"`
+	Title       string         `json:"title"`
+	Slug        string         `json:"slug"`
+	Excerpt     string         `json:"excerpt"`
+	Status      PostStatus     `json:"status"`
+	CategoryID  uuid.UUID      `json:"category_id"`
+	Tags        pq.StringArray `gorm:"type:text[]" json:"tags"`
+	Views       int            `json:"views"`
+	Likes       int            `json:"likes"`
+	Thumbnail   string         `json:"thumbnail"`
+	Version     int            `json:"version"`
+	PublishedAt *time.Time     `json:"published_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// PostPage 文章列表的一页
+type PostPage struct {
+	Items      []*PostSummary `json:"items"`
+	Total      int64          `json:"total"`
+	PageSize   int            `json:"page_size"`
+	Page       int            `json:"page,omitempty"` // 偏移分页时的页码
+	HasMore    bool           `json:"has_more"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
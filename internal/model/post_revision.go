@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostRevision represents the 'post_revisions' table.
+// 文章每次保存后的快照，UserID 为保存这一版本的用户
+type PostRevision struct {
+	ID              uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	PostID          uint           `gorm:"not null;index" json:"post_id"`
+	UserID          *uuid.UUID     `gorm:"type:uuid" json:"user_id"`
+	Title           string         `gorm:"type:text;not null" json:"title"`
+	Content         string         `gorm:"type:text" json:"content"`
+	Excerpt         string         `gorm:"type:text" json:"excerpt"`
+	Tags            pq.StringArray `gorm:"type:text[]" json:"tags"`
+	MetaTitle       string         `gorm:"type:text" json:"meta_title"`
+	MetaDescription string         `gorm:"type:text" json:"meta_description"`
+	CreatedAt       time.Time      `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+func (PostRevision) TableName() string {
+	return "blog.post_revisions"
+}
+
+// NewPostRevision 根据文章当前内容创建快照，userID 为 uuid.Nil 表示由系统保存
+func NewPostRevision(post *Post, userID uuid.UUID) *PostRevision {
+	revision := &PostRevision{
+		PostID:          post.ID,
+		Title:           post.Title,
+		Content:         post.Content,
+		Excerpt:         post.Excerpt,
+		Tags:            post.Tags,
+		MetaTitle:       post.MetaTitle,
+		MetaDescription: post.MetaDescription,
+	}
+	if userID != uuid.Nil {
+		revision.UserID = &userID
+	}
+	return revision
+}
+
+// PostRevisionSummary 版本列表项，不包含正文
+type PostRevisionSummary struct {
+	ID        uint       `json:"id"`
+	PostID    uint       `json:"post_id"`
+	UserID    *uuid.UUID `json:"user_id"`
+	Username  *string    `json:"username"`
+	Title     string     `json:"title"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PostRevisionDiff 两个版本之间的统一格式差异
+type PostRevisionDiff struct {
+	From uint   `json:"from"`
+	To   uint   `json:"to"`
+	Diff string `json:"diff"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// PostSlug represents the 'post_slugs' table.
+// 记录文章用过的旧 slug，访问旧地址时永久重定向到当前 slug
+type PostSlug struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PostID    uint      `gorm:"not null;index" json:"post_id"`
+	Slug      string    `gorm:"type:text;not null;uniqueIndex" json:"slug"`
+	CreatedAt time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+func (PostSlug) TableName() string {
+	return "blog.post_slugs"
+}
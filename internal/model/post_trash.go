@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+// TrashedPost 回收站中的文章，DeletedAt 为移入回收站的时间
+type TrashedPost struct {
+	PostSummary
+	DeletedAt time.Time `json:"deleted_at"`
+}
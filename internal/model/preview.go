@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// CreatePreviewLinkRequest 创建预览链接请求结构体
+type CreatePreviewLinkRequest struct {
+	// RevisionID 要预览的版本，为 0 时使用最新版本
+	RevisionID uint `json:"revision_id"`
+	// ExpiresIn 有效期（秒），为 0 时使用默认有效期
+	ExpiresIn int `json:"expires_in"`
+}
+
+// PreviewLink 签名的预览链接，持有链接的人无需登录即可查看指定版本
+type PreviewLink struct {
+	Token      string    `json:"token"`
+	URL        string    `json:"url"`
+	PostID     uint      `json:"post_id"`
+	RevisionID uint      `json:"revision_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
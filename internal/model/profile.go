@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// UpdateProfileRequest 修改个人资料请求结构体，未提供的字段保持不变
+type UpdateProfileRequest struct {
+	Nickname *string `json:"nickname"`
+	Email    *string `json:"email"`
+	Avatar   *string `json:"avatar"`
+	Bio      *string `json:"bio"`
+}
+
+// Profile 当前用户的个人资料
+type Profile struct {
+	*User
+	PendingEmail string `json:"pending_email,omitempty"` // 已申请修改、尚未验证的新邮箱
+}
+
+// AuthorProfile 作者公开主页，只包含可以公开的字段
+type AuthorProfile struct {
+	Username string          `json:"username"`
+	Nickname string          `json:"nickname"`
+	Avatar   string          `json:"avatar"`
+	Bio      string          `json:"bio"`
+	JoinedAt time.Time       `json:"joined_at"`
+	Posts    []*PostFrontend `json:"posts"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecoveryCode represents the 'recovery_codes' table.
+// 两步验证的一次性恢复码，只保存哈希
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:text;not null" json:"-"`
+	UsedAt    *time.Time `gorm:"type:timestamptz" json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "admin.recovery_codes"
+}
@@ -10,7 +10,9 @@ import (
 type RefreshToken struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
-	TokenHash string    `gorm:"type:text;not null" json:"-"` // 绝不返回！
+	TokenHash string    `gorm:"type:text;not null;uniqueIndex" json:"-"` // 绝不返回！
+	// FamilyID 标识同一次登录轮换出的所有刷新令牌，检测到重放时整族撤销
+	FamilyID uuid.UUID `gorm:"type:uuid;not null;index" json:"family_id"`
 
 	UserAgent string    `gorm:"type:text" json:"user_agent,omitempty"`
 	IPAddress string    `gorm:"type:inet" json:"ip_address,omitempty"` // GORM 不直接支持 INET，用 string 存 IP
@@ -25,3 +27,13 @@ type RefreshToken struct {
 func (RefreshToken) TableName() string {
 	return "admin.refresh_tokens"
 }
+
+// Session 是返回给前端的会话信息，一个会话对应一个刷新令牌族
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	UserAgent    string    `json:"user_agent"`
+	IPAddress    string    `json:"ip_address"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Current      bool      `json:"current"`
+}
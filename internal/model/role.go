@@ -0,0 +1,57 @@
+package model
+
+// 权限标识，格式为 资源:操作[:范围]
+const (
+	PermPostCreate     = "post:create"
+	PermPostEditOwn    = "post:edit:own"
+	PermPostEditAny    = "post:edit:any"
+	PermPostDeleteOwn  = "post:delete:own"
+	PermPostDeleteAny  = "post:delete:any"
+	PermPostPublish    = "post:publish"
+	PermCategoryManage = "category:manage"
+	PermUserManage     = "user:manage"
+	PermUserInvite     = "user:invite"
+	PermAuditRead      = "audit:read"
+)
+
+// AllPermissions 系统中定义的全部权限
+var AllPermissions = []string{
+	PermPostCreate,
+	PermPostEditOwn,
+	PermPostEditAny,
+	PermPostDeleteOwn,
+	PermPostDeleteAny,
+	PermPostPublish,
+	PermCategoryManage,
+	PermUserManage,
+	PermUserInvite,
+	PermAuditRead,
+}
+
+// 内置角色
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleAuthor = "author"
+	RoleReader = "reader"
+)
+
+// Role represents the 'roles' table.
+type Role struct {
+	Name        string `gorm:"type:text;primaryKey" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+}
+
+func (Role) TableName() string {
+	return "admin.roles"
+}
+
+// RolePermission represents the 'role_permissions' table.
+type RolePermission struct {
+	Role       string `gorm:"type:text;primaryKey" json:"role"`
+	Permission string `gorm:"type:text;primaryKey" json:"permission"`
+}
+
+func (RolePermission) TableName() string {
+	return "admin.role_permissions"
+}
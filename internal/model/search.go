@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostSearchQuery 全文搜索条件
+type PostSearchQuery struct {
+	Text       string
+	CategoryID *uuid.UUID
+	Tag        string
+	Limit      int
+	Offset     int
+}
+
+// PostSearchHit 搜索命中的文章ID及相关度，按相关度排序后再读取展示字段
+// 全文检索命中时 Snippet 为 ts_headline 生成的原始片段，模糊匹配时为空
+type PostSearchHit struct {
+	ID      uint
+	Rank    float64
+	Snippet string
+}
+
+// PostSearchResult 搜索结果，Highlight 和 Snippet 中的命中词用 <mark> 包裹，其余内容已转义
+type PostSearchResult struct {
+	ID          uint           `json:"id"`
+	Title       string         `json:"title"`
+	Slug        string         `json:"slug"`
+	Excerpt     string         `json:"excerpt"`
+	Status      PostStatus     `json:"status"`
+	CategoryID  uuid.UUID      `json:"category_id"`
+	Tags        pq.StringArray `gorm:"type:text[]" json:"tags"`
+	PublishedAt *time.Time     `json:"published_at"`
+	Rank        float64        `gorm:"-" json:"rank"`
+	Fuzzy       bool           `gorm:"-" json:"fuzzy"`     // 由模糊匹配得到，而非全文检索
+	Highlight   string         `gorm:"-" json:"highlight"` // 高亮后的标题
+	Snippet     string         `gorm:"-" json:"snippet"`   // 正文中命中的片段
+}
+
+// Suggestion 搜索框输入提示
+type Suggestion struct {
+	Type   string  `json:"type"` // post 或 tag
+	Text   string  `json:"text"`
+	PostID uint    `json:"post_id,omitempty"`
+	Score  float64 `json:"-"`
+}
@@ -17,6 +17,19 @@ type User struct {
 	Avatar       string    `gorm:"type:text" json:"avatar"`
 	Bio          string    `gorm:"type:text" json:"bio"`
 	IsAdmin      bool      `gorm:"type:boolean;not null;default:false" json:"is_admin"`
+	Role         string    `gorm:"type:text;not null;default:reader" json:"role"` // 对应 admin.roles.name
+
+	EmailVerifiedAt *time.Time `gorm:"type:timestamp with time zone" json:"email_verified_at,omitempty"`
+
+	// DisabledAt 非空表示账号已被管理员停用，停用后立即无法访问接口
+	DisabledAt *time.Time `gorm:"type:timestamp with time zone" json:"disabled_at,omitempty"`
+	// PasswordResetRequired 管理员要求用户重置密码，重置前无法使用密码登录
+	PasswordResetRequired bool `gorm:"type:boolean;not null;default:false" json:"password_reset_required"`
+
+	// 两步验证：TOTPSecret 在启用前为待确认的密钥，TOTPLastStep 用于拒绝验证码重放
+	TOTPSecret   string `gorm:"column:totp_secret;type:text" json:"-"`
+	TOTPEnabled  bool   `gorm:"column:totp_enabled;type:boolean;not null;default:false" json:"totp_enabled"`
+	TOTPLastStep int64  `gorm:"column:totp_last_step;type:bigint;not null;default:0" json:"-"`
 
 	// GORM 自动管理时间戳（需嵌入 gorm.Model 或手动声明）
 	CreatedAt time.Time      `gorm:"type:timestamp with time zone;not null" json:"created_at"`
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity represents the 'user_identities' table.
+// 记录用户绑定的第三方账号，(provider, subject) 唯一
+type UserIdentity struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider  string    `gorm:"type:text;not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	Subject   string    `gorm:"type:text;not null;uniqueIndex:idx_user_identities_provider_subject" json:"subject"`
+	Email     string    `gorm:"type:text" json:"email"`
+	CreatedAt time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+func (UserIdentity) TableName() string {
+	return "admin.user_identities"
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserToken 的用途
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+	TokenPurposeChangeEmail   = "change_email"
+)
+
+// UserToken represents the 'user_tokens' table.
+// 邮件中发送的一次性令牌，只保存哈希
+type UserToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Purpose   string     `gorm:"type:text;not null" json:"purpose"`
+	TokenHash string     `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	Payload   string     `gorm:"type:text" json:"-"` // 附加数据，如待验证的新邮箱
+	ExpiresAt time.Time  `gorm:"type:timestamptz;not null" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"type:timestamptz" json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+func (UserToken) TableName() string {
+	return "admin.user_tokens"
+}
@@ -0,0 +1,97 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+var ErrUnknownProvider = errors.New("unknown oauth provider")
+
+// Identity 是第三方账号的基本信息
+type Identity struct {
+	Subject       string // 第三方平台上的唯一用户标识
+	Email         string
+	EmailVerified bool
+	Name          string
+	AvatarURL     string
+}
+
+// Provider 是一个第三方登录提供方
+type Provider interface {
+	Name() string
+	// AuthCodeURL 返回授权页地址，verifier 用于生成 PKCE challenge，nonce 用于 OIDC 防重放
+	AuthCodeURL(state, verifier, nonce string) string
+	// Exchange 用授权码换取令牌并获取用户身份
+	Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error)
+}
+
+// Config 描述一个提供方的配置
+type Config struct {
+	Name         string
+	Type         string // oidc 或 github
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// Issuer OIDC 提供方的 issuer 地址，启动时通过 /.well-known/openid-configuration 自动发现端点，
+	// 本地测试时可以指向 mock OIDC 服务
+	Issuer string
+	// AuthURL / TokenURL / APIURL 覆盖 GitHub 的默认地址，便于对接 GitHub Enterprise 或 mock 服务
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+}
+
+// Registry 按名称管理已配置的提供方
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry 根据配置创建所有提供方，初始化失败的提供方会被跳过并记录日志，不影响服务启动
+func NewRegistry(ctx context.Context, configs []Config) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, cfg := range configs {
+		provider, err := newProvider(ctx, cfg)
+		if err != nil {
+			log.Printf("⚠️  oauth provider %s disabled: %v", cfg.Name, err)
+			continue
+		}
+		r.providers[cfg.Name] = provider
+	}
+	return r
+}
+
+func newProvider(ctx context.Context, cfg Config) (Provider, error) {
+	if cfg.ClientID == "" {
+		return nil, errors.New("client id is required")
+	}
+	switch cfg.Type {
+	case "github":
+		return NewGitHubProvider(cfg), nil
+	case "oidc":
+		return NewOIDCProvider(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unsupported provider type %q", cfg.Type)
+	}
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// Names 返回所有可用提供方的名称，供前端渲染登录按钮
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
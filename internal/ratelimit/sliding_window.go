@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// SlidingWindow 按键记录最近一个时间窗口内发生的事件，用于失败次数统计和限流
+// 数据只保存在内存中，多实例部署时每个实例单独计数
+type SlidingWindow struct {
+	mu     sync.Mutex
+	window time.Duration
+	events map[string][]time.Time
+}
+
+func NewSlidingWindow(window time.Duration) *SlidingWindow {
+	w := &SlidingWindow{
+		window: window,
+		events: make(map[string][]time.Time),
+	}
+	go w.cleanupLoop()
+	return w
+}
+
+// Add 记录一次事件，返回窗口内（含本次）的事件数
+func (w *SlidingWindow) Add(key string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	events := append(w.prune(key, now), now)
+	w.events[key] = events
+	return len(events)
+}
+
+// Count 返回窗口内的事件数
+func (w *SlidingWindow) Count(key string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.prune(key, time.Now()))
+}
+
+// Last 返回最近一次事件的时间，窗口内没有事件时返回零值
+func (w *SlidingWindow) Last(key string) time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	events := w.prune(key, time.Now())
+	if len(events) == 0 {
+		return time.Time{}
+	}
+	return events[len(events)-1]
+}
+
+// RetryAfter 返回窗口内事件数降到 limit 以下还需等待的时间，未达到 limit 时返回 0
+func (w *SlidingWindow) RetryAfter(key string, limit int) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	events := w.prune(key, now)
+	if len(events) < limit {
+		return 0
+	}
+	// 需要等到第 len-limit+1 早的事件滑出窗口
+	return events[len(events)-limit].Add(w.window).Sub(now)
+}
+
+// Allow 在同一把锁内完成检查和记录：窗口内事件数未达到 limit 时记录一次事件并返回 0，
+// 否则不记录并返回还需等待的时间。用于先预占一次尝试再做耗时校验，避免并发请求同时通过检查
+func (w *SlidingWindow) Allow(key string, limit int) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	events := w.prune(key, now)
+	if limit <= 0 {
+		return w.window
+	}
+	if len(events) >= limit {
+		return events[len(events)-limit].Add(w.window).Sub(now)
+	}
+	w.events[key] = append(events, now)
+	return 0
+}
+
+// Release 撤销最近一次记录的事件，用于校验通过后归还 Allow 预占的次数
+func (w *SlidingWindow) Release(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	events := w.prune(key, time.Now())
+	if len(events) <= 1 {
+		delete(w.events, key)
+		return
+	}
+	w.events[key] = events[:len(events)-1]
+}
+
+// Reset 清除某个键的全部记录
+func (w *SlidingWindow) Reset(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.events, key)
+}
+
+// prune 丢弃已滑出窗口的事件，调用方需持有锁
+func (w *SlidingWindow) prune(key string, now time.Time) []time.Time {
+	events := w.events[key]
+	cutoff := now.Add(-w.window)
+	i := 0
+	for i < len(events) && !events[i].After(cutoff) {
+		i++
+	}
+	if i == len(events) {
+		delete(w.events, key)
+		return nil
+	}
+	events = events[i:]
+	w.events[key] = events
+	return events
+}
+
+func (w *SlidingWindow) cleanupLoop() {
+	ticker := time.NewTicker(w.window)
+	defer ticker.Stop()
+	for range ticker.C {
+		w.mu.Lock()
+		now := time.Now()
+		for key := range w.events {
+			w.prune(key, now)
+		}
+		w.mu.Unlock()
+	}
+}
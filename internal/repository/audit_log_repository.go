@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"crist-blog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository 审计日志只追加，不提供修改和删除
+type AuditLogRepository struct {
+	DB *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{
+		DB: db,
+	}
+}
+
+func (r *AuditLogRepository) Create(entry *model.AuditLog) error {
+	return r.DB.Create(entry).Error
+}
+
+func (r *AuditLogRepository) filtered(filter model.AuditLogFilter) *gorm.DB {
+	query := r.DB.Model(&model.AuditLog{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+// List 分页查询审计日志，按时间倒序，同时返回符合条件的总数
+func (r *AuditLogRepository) List(filter model.AuditLogFilter, offset, limit int) ([]*model.AuditLog, int64, error) {
+	var total int64
+	if err := r.filtered(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []*model.AuditLog
+	err := r.filtered(filter).
+		Order("created_at desc").
+		Offset(offset).
+		Limit(limit).
+		Find(&logs).Error
+	return logs, total, err
+}
+
+// Each 按时间倒序逐条遍历符合条件的审计日志，用于导出时避免一次性加载全部记录
+func (r *AuditLogRepository) Each(filter model.AuditLogFilter, fn func(*model.AuditLog) error) error {
+	rows, err := r.filtered(filter).Order("created_at desc").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry model.AuditLog
+		if err := r.DB.ScanRows(rows, &entry); err != nil {
+			return err
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
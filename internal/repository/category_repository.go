@@ -30,3 +30,30 @@ func (r *CategoryRepository) ListAllCategories() ([]model.Category, error) {
 		Find(&categories).Error
 	return categories, err
 }
+
+func (r *CategoryRepository) GetByID(id uuid.UUID) (*model.Category, error) {
+	var category model.Category
+	err := r.DB.Where("id = ?", id).First(&category).Error
+	return &category, err
+}
+
+func (r *CategoryRepository) Create(category *model.Category) error {
+	return r.DB.Create(category).Error
+}
+
+func (r *CategoryRepository) Update(category *model.Category) error {
+	return r.DB.Save(category).Error
+}
+
+func (r *CategoryRepository) Delete(id uuid.UUID) error {
+	return r.DB.Where("id = ?", id).Delete(&model.Category{}).Error
+}
+
+// CountPosts 统计分类下的文章数（含已软删除的文章）
+func (r *CategoryRepository) CountPosts(id uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.Model(&model.Post{}).Unscoped().
+		Where("category_id = ?", id).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"crist-blog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type InvitationRepository struct {
+	DB *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) *InvitationRepository {
+	return &InvitationRepository{
+		DB: db,
+	}
+}
+
+func (r *InvitationRepository) Create(invitation *model.Invitation) error {
+	return r.DB.Create(invitation).Error
+}
+
+func (r *InvitationRepository) FindByCodeHash(hash string) (*model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.DB.Where("code_hash = ?", hash).First(&invitation).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *InvitationRepository) List() ([]*model.Invitation, error) {
+	var invitations []*model.Invitation
+	err := r.DB.Order("created_at desc").Find(&invitations).Error
+	return invitations, err
+}
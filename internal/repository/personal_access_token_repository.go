@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"crist-blog/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PersonalAccessTokenRepository struct {
+	DB *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{
+		DB: db,
+	}
+}
+
+func (r *PersonalAccessTokenRepository) Create(token *model.PersonalAccessToken) error {
+	return r.DB.Create(token).Error
+}
+
+func (r *PersonalAccessTokenRepository) FindByTokenHash(hash string) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	if err := r.DB.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *PersonalAccessTokenRepository) ListByUserID(userID uuid.UUID) ([]*model.PersonalAccessToken, error) {
+	var tokens []*model.PersonalAccessToken
+	err := r.DB.Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke 撤销属于指定用户的令牌，返回被撤销的行数
+func (r *PersonalAccessTokenRepository) Revoke(userID, id uuid.UUID) (int64, error) {
+	result := r.DB.Model(&model.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *PersonalAccessTokenRepository) TouchLastUsed(id uuid.UUID, at time.Time) error {
+	return r.DB.Model(&model.PersonalAccessToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"crist-blog/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostEditRepository 管理文章的编辑锁和自动保存草稿
+type PostEditRepository struct {
+	DB *gorm.DB
+}
+
+func NewPostEditRepository(db *gorm.DB) *PostEditRepository {
+	return &PostEditRepository{DB: db}
+}
+
+// AcquireLock 原子地获取编辑锁：锁不存在、已过期、本来就由该用户持有或 takeover 为 true 时成功
+// 该用户续期时保留原来的获取时间
+func (r *PostEditRepository) AcquireLock(postID uint, userID uuid.UUID, expiresAt time.Time, takeover bool) (bool, error) {
+	now := time.Now()
+	result := r.DB.Exec(`INSERT INTO blog.post_locks AS l (post_id, user_id, acquired_at, expires_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (post_id) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			acquired_at = CASE WHEN l.user_id = EXCLUDED.user_id AND l.expires_at > ? THEN l.acquired_at ELSE EXCLUDED.acquired_at END,
+			expires_at = EXCLUDED.expires_at
+		WHERE l.user_id = EXCLUDED.user_id OR l.expires_at <= ? OR ?`,
+		postID, userID, now, expiresAt, now, now, takeover)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RenewLock 延长用户持有的锁，锁已被他人接管时返回 false
+func (r *PostEditRepository) RenewLock(postID uint, userID uuid.UUID, expiresAt time.Time) (bool, error) {
+	result := r.DB.Model(&model.PostLock{}).
+		Where("post_id = ? AND user_id = ?", postID, userID).
+		Update("expires_at", expiresAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReleaseLock 释放用户持有的锁，锁已被他人接管时不做任何事
+func (r *PostEditRepository) ReleaseLock(postID uint, userID uuid.UUID) error {
+	return r.DB.Where("post_id = ? AND user_id = ?", postID, userID).Delete(&model.PostLock{}).Error
+}
+
+// GetLock 读取文章当前有效的锁及持有者用户名，没有时返回 gorm.ErrRecordNotFound
+func (r *PostEditRepository) GetLock(postID uint) (*model.PostLock, error) {
+	var lock model.PostLock
+	err := r.DB.Table("blog.post_locks AS l").
+		Select("l.post_id, l.user_id, u.username, l.acquired_at, l.expires_at").
+		Joins("LEFT JOIN admin.users u ON u.id = l.user_id").
+		Where("l.post_id = ? AND l.expires_at > ?", postID, time.Now()).
+		Take(&lock).Error
+	return &lock, err
+}
+
+// SaveAutosave 新建或覆盖用户对文章的自动保存草稿
+func (r *PostEditRepository) SaveAutosave(autosave *model.PostAutosave) error {
+	return r.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(autosave).Error
+}
+
+func (r *PostEditRepository) GetAutosave(postID uint, userID uuid.UUID) (*model.PostAutosave, error) {
+	var autosave model.PostAutosave
+	err := r.DB.Where("post_id = ? AND user_id = ?", postID, userID).First(&autosave).Error
+	return &autosave, err
+}
+
+func (r *PostEditRepository) DeleteAutosave(postID uint, userID uuid.UUID) error {
+	return r.DB.Where("post_id = ? AND user_id = ?", postID, userID).Delete(&model.PostAutosave{}).Error
+}
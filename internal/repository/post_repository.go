@@ -2,10 +2,37 @@ package repository
 
 import (
 	"crist-blog/internal/model"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// postSortColumns 排序字段对应的 SQL 表达式，未发布的文章没有发布时间，按创建时间排序
+var postSortColumns = map[string]string{
+	model.PostSortPublishedAt: "COALESCE(published_at, created_at)",
+	model.PostSortViews:       "views",
+	model.PostSortLikes:       "likes",
+}
+
+// postSummaryColumns 列表查询的字段，不读取正文
+const postSummaryColumns = "id, user_id, title, slug, excerpt, status, category_id, tags, views, likes, thumbnail, version, published_at, created_at, updated_at"
+
+// ErrStaleVersion 保存文章时版本号不一致，说明文章在读取之后被他人修改
+var ErrStaleVersion = errors.New("post was modified concurrently")
+
+// listedStatuses 出现在公开列表和搜索中的状态，受密码保护的文章只展示摘要
+var listedStatuses = []model.PostStatus{model.Published, model.Protected}
+
+// published 只保留公开列出的文章，定时发布的文章在发布前不可见
+func published(db *gorm.DB) *gorm.DB {
+	return db.Where("status IN ?", listedStatuses)
+}
+
 type PostRepository struct {
 	DB *gorm.DB
 }
@@ -24,14 +51,183 @@ func (r *PostRepository) GetByID(id uint) (*model.Post, error) {
 	return &post, err
 }
 
-func (r *PostRepository) Update(post *model.Post) error {
-	return r.DB.Save(post).Error
+// Update 保存文章并把版本号加一，文章已被他人修改（版本号不一致）时返回 ErrStaleVersion
+// slug 发生变化时把旧 slug 记入历史，以便旧地址重定向；文章改回自己用过的 slug 时删除对应的历史记录
+func (r *PostRepository) Update(post *model.Post, previousSlug string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if previousSlug != "" && previousSlug != post.Slug {
+			if err := tx.Where("slug = ? AND post_id = ?", post.Slug, post.ID).Delete(&model.PostSlug{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&model.PostSlug{PostID: post.ID, Slug: previousSlug}).Error; err != nil {
+				return err
+			}
+		}
+		return updateVersioned(tx, post)
+	})
+}
+
+// updateVersioned 在版本号未变化的前提下保存文章并把版本号加一
+func updateVersioned(tx *gorm.DB, post *model.Post) error {
+	version := post.Version
+	post.Version++
+	result := tx.Model(post).Where("version = ?", version).Select("*").Omit("id", "created_at").Updates(post)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrStaleVersion
+	}
+	if result.Error != nil {
+		post.Version = version
+	}
+	return result.Error
+}
+
+// UpdateAll 在一个事务中保存多篇文章，任意一篇版本冲突或出错时全部回滚
+func (r *PostRepository) UpdateAll(posts []*model.Post) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, post := range posts {
+			if err := updateVersioned(tx, post); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteAll 把多篇文章移入回收站
+func (r *PostRepository) DeleteAll(ids []uint) error {
+	return r.DB.Where("id IN ?", ids).Delete(&model.Post{}).Error
+}
+
+// RestoreAll 从回收站恢复多篇文章
+func (r *PostRepository) RestoreAll(ids []uint) error {
+	return r.DB.Unscoped().Model(&model.Post{}).
+		Where("id IN ? AND deleted_at IS NOT NULL", ids).
+		Update("deleted_at", nil).Error
+}
+
+// GetByIDs 读取多篇文章，trashed 为 true 时只在回收站中查找
+func (r *PostRepository) GetByIDs(ids []uint, trashed bool) ([]*model.Post, error) {
+	query := r.DB.Where("id IN ?", ids)
+	if trashed {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	var posts []*model.Post
+	return posts, query.Find(&posts).Error
+}
+
+// ListIDs 按条件列出文章ID，最多 limit 个，trashed 为 true 时只在回收站中查找
+func (r *PostRepository) ListIDs(q *model.PostQuery, trashed bool, limit int) ([]uint, error) {
+	query := r.filteredPosts(q)
+	if trashed {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	var ids []uint
+	err := query.Order("id").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// PublishDue 发布已到时间的定时文章，返回本次发布的文章
+// 使用 FOR UPDATE SKIP LOCKED，多个实例同时执行时每篇文章只会被其中一个发布
+func (r *PostRepository) PublishDue(now time.Time, limit int) ([]*model.Post, error) {
+	var posts []*model.Post
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND published_at <= ?", model.Scheduled, now).
+			Order("published_at").
+			Limit(limit).
+			Find(&posts).Error
+		if err != nil || len(posts) == 0 {
+			return err
+		}
+		ids := make([]uint, 0, len(posts))
+		for _, post := range posts {
+			post.Status = model.Published
+			post.Version++
+			ids = append(ids, post.ID)
+		}
+		// 版本号加一，使发布前打开的编辑器保存时得到冲突提示
+		return tx.Model(&model.Post{}).Where("id IN ?", ids).
+			UpdateColumns(map[string]interface{}{"status": model.Published, "version": gorm.Expr("version + 1")}).Error
+	})
+	return posts, err
+}
+
+// GetBySlug 按当前 slug 查找文章
+func (r *PostRepository) GetBySlug(slug string) (*model.Post, error) {
+	var post model.Post
+	err := r.DB.Where("slug = ?", slug).First(&post).Error
+	return &post, err
+}
+
+// GetBySlugHistory 按历史 slug 查找文章，返回的文章带有当前 slug
+func (r *PostRepository) GetBySlugHistory(slug string) (*model.Post, error) {
+	var post model.Post
+	err := r.DB.Where("id = (?)", r.DB.Model(&model.PostSlug{}).Select("post_id").Where("slug = ?", slug)).
+		First(&post).Error
+	return &post, err
+}
+
+// SlugTaken 判断 slug 是否已被其他文章（包括已删除的文章）或其他文章的历史记录占用
+func (r *PostRepository) SlugTaken(slug string, excludeID uint) (bool, error) {
+	var count int64
+	err := r.DB.Unscoped().Model(&model.Post{}).Where("slug = ? AND id <> ?", slug, excludeID).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	err = r.DB.Model(&model.PostSlug{}).Where("slug = ? AND post_id <> ?", slug, excludeID).Count(&count).Error
+	return count > 0, err
 }
 
 func (r *PostRepository) Delete(id uint) error {
 	return r.DB.Where("id = ?", id).Delete(&model.Post{}).Error
 }
 
+// ListTrashed 分页列出回收站中的文章，最近删除的在前
+func (r *PostRepository) ListTrashed(offset, limit int) ([]*model.TrashedPost, int64, error) {
+	query := r.DB.Unscoped().Model(&model.Post{}).Where("deleted_at IS NOT NULL")
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var posts []*model.TrashedPost
+	err := query.Select(postSummaryColumns + ", deleted_at").
+		Order("deleted_at desc, id desc").
+		Offset(offset).
+		Limit(limit).
+		Scan(&posts).Error
+	return posts, total, err
+}
+
+// Restore 把回收站中的文章恢复，文章不在回收站时返回 0
+func (r *PostRepository) Restore(id uint) (int64, error) {
+	result := r.DB.Unscoped().Model(&model.Post{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	return result.RowsAffected, result.Error
+}
+
+// Purge 永久删除回收站中的文章，版本、slug 历史、编辑锁和自动保存由外键级联删除
+// 文章不在回收站时返回 0
+func (r *PostRepository) Purge(id uint) (int64, error) {
+	result := r.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(&model.Post{})
+	return result.RowsAffected, result.Error
+}
+
+// PurgeTrashedBefore 永久删除在 cutoff 之前移入回收站的文章，每次最多 limit 篇，返回被删除的文章ID
+// 使用 FOR UPDATE SKIP LOCKED，多个实例同时执行时互不等待
+func (r *PostRepository) PurgeTrashedBefore(cutoff time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Raw(`DELETE FROM blog.posts WHERE id IN (
+			SELECT id FROM blog.posts
+			WHERE deleted_at IS NOT NULL AND deleted_at < ?
+			ORDER BY deleted_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		) RETURNING id`, cutoff, limit).
+		Scan(&ids).Error
+	return ids, err
+}
+
 func (r *PostRepository) List() ([]*model.Post, error) {
 	var posts []*model.Post
 	return posts, r.DB.Find(&posts).Error
@@ -40,8 +236,8 @@ func (r *PostRepository) List() ([]*model.Post, error) {
 func (r *PostRepository) GetHotPost() ([]*model.HotPost, error) {
 	var hotPosts []*model.HotPost
 	err := r.DB.Model(&model.Post{}).
-		Select("id, title, category_id, created_at, excerpt").
-		Where("status = ?", model.Published).
+		Select("id, title, status, category_id, created_at, excerpt").
+		Scopes(published).
 		Order("likes desc").
 		Limit(2).
 		Find(&hotPosts).Error
@@ -51,10 +247,212 @@ func (r *PostRepository) GetHotPost() ([]*model.HotPost, error) {
 func (r *PostRepository) GetLatestPosts() ([]*model.LatestPost, error) {
 	var latestPosts []*model.LatestPost
 	err := r.DB.Model(&model.Post{}).
-		Select("id, title, category_id, created_at").
-		Where("status = ?", model.Published).
+		Select("id, title, status, category_id, created_at").
+		Scopes(published).
 		Order("created_at desc").
 		Limit(3).
 		Find(&latestPosts).Error
 	return latestPosts, err
 }
+
+// ListPublishedByUserID 列出作者公开列出的文章，按发布时间倒序
+func (r *PostRepository) ListPublishedByUserID(userID uuid.UUID) ([]*model.Post, error) {
+	var posts []*model.Post
+	err := r.DB.Scopes(published).Where("user_id = ?", userID).
+		Order("published_at desc").
+		Find(&posts).Error
+	return posts, err
+}
+
+// filteredPosts 按查询条件过滤文章，不包含分页和排序
+func (r *PostRepository) filteredPosts(q *model.PostQuery) *gorm.DB {
+	query := r.DB.Model(&model.Post{})
+	if q.CategoryID != nil {
+		query = query.Where("category_id = ?", *q.CategoryID)
+	}
+	if q.Tag != "" {
+		// 使用 @> 而不是 = ANY，才能命中 tags 上的 GIN 索引
+		query = query.Where("tags @> ?", pq.StringArray{q.Tag})
+	}
+	if q.AuthorID != nil {
+		query = query.Where("user_id = ?", *q.AuthorID)
+	}
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.Listed {
+		query = query.Scopes(published)
+	}
+	if q.From != nil {
+		query = query.Where("COALESCE(published_at, created_at) >= ?", *q.From)
+	}
+	if q.To != nil {
+		query = query.Where("COALESCE(published_at, created_at) < ?", *q.To)
+	}
+	return query
+}
+
+// Query 按条件分页查询文章摘要，返回符合条件的总数
+// 排序总是以 id 作为第二排序键，保证游标分页的顺序稳定
+func (r *PostRepository) Query(q *model.PostQuery) ([]*model.PostSummary, int64, error) {
+	column, ok := postSortColumns[q.Sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported sort field %q", q.Sort)
+	}
+	var total int64
+	if err := r.filteredPosts(q).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	direction, comparator := "desc", "<"
+	if q.Ascending {
+		direction, comparator = "asc", ">"
+	}
+	query := r.filteredPosts(q).Select(postSummaryColumns)
+	if q.After != nil {
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparator), q.After.Value, q.After.ID)
+	} else if q.Offset > 0 {
+		query = query.Offset(q.Offset)
+	}
+	var posts []*model.PostSummary
+	err := query.
+		Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(q.Limit).
+		Find(&posts).Error
+	return posts, total, err
+}
+
+// ListPublishedSummaries 列出全部公开列出文章的摘要，按发布时间倒序
+func (r *PostRepository) ListPublishedSummaries() ([]*model.PostSummary, error) {
+	var posts []*model.PostSummary
+	err := r.DB.Model(&model.Post{}).
+		Select(postSummaryColumns).
+		Scopes(published).
+		Order("published_at desc, id desc").
+		Find(&posts).Error
+	return posts, err
+}
+
+// UpdateSearchIndex 重新计算文章的全文检索向量和拼音，标题、摘要、正文的权重依次为 A、B、C
+// 传入的文本已经过分词，只更新检索相关的列，不影响 updated_at
+func (r *PostRepository) UpdateSearchIndex(id uint, config, title, excerpt, content, pinyin, document string) error {
+	return r.DB.Model(&model.Post{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"search_vector": gorm.Expr(
+				"setweight(to_tsvector(?::regconfig, ?), 'A') || "+
+					"setweight(to_tsvector(?::regconfig, ?), 'B') || "+
+					"setweight(to_tsvector(?::regconfig, ?), 'C')",
+				config, title, config, excerpt, config, content),
+			"search_pinyin":   pinyin,
+			"search_document": document,
+		}).Error
+}
+
+// ListAllIDs 列出全部文章ID，用于重建检索索引
+func (r *PostRepository) ListAllIDs() ([]uint, error) {
+	var ids []uint
+	err := r.DB.Model(&model.Post{}).Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// SearchPublished 在已发布文章中全文检索，返回按相关度排序的一页命中结果和命中总数
+// 片段由 ts_headline 按 headlineOptions 从 search_document 生成，只对当前页的结果计算
+func (r *PostRepository) SearchPublished(config, headlineOptions string, q *model.PostSearchQuery) ([]*model.PostSearchHit, int64, error) {
+	tsQuery := gorm.Expr("websearch_to_tsquery(?::regconfig, ?)", config, q.Text)
+	query := r.DB.Model(&model.Post{}).
+		Scopes(published).
+		Where("search_vector @@ ?", tsQuery)
+	if q.CategoryID != nil {
+		query = query.Where("category_id = ?", *q.CategoryID)
+	}
+	if q.Tag != "" {
+		query = query.Where("tags @> ?", pq.StringArray{q.Tag})
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	page := query.
+		Select("id, search_document, ts_rank_cd(search_vector, ?) AS rank", tsQuery).
+		Order("rank desc, id desc").
+		Limit(q.Limit).
+		Offset(q.Offset)
+	var hits []*model.PostSearchHit
+	err := r.DB.Table("(?) AS hits", page).
+		Select("id, rank, ts_headline(?::regconfig, search_document, ?, ?) AS snippet", config, tsQuery, headlineOptions).
+		Order("rank desc, id desc").
+		Scan(&hits).Error
+	return hits, total, err
+}
+
+// FuzzySearchPublished 按标题、标签和拼音的三元组相似度模糊匹配已发布文章，用于全文检索无结果时容错
+func (r *PostRepository) FuzzySearchPublished(q *model.PostSearchQuery) ([]*model.PostSearchHit, int64, error) {
+	score := gorm.Expr("GREATEST(word_similarity(?, title), word_similarity(?, blog.tags_text(tags)), word_similarity(?, coalesce(search_pinyin, '')))",
+		q.Text, q.Text, q.Text)
+	query := r.DB.Model(&model.Post{}).
+		Scopes(published).
+		Where("(? <% title OR ? <% blog.tags_text(tags) OR ? <% coalesce(search_pinyin, ''))", q.Text, q.Text, q.Text)
+	if q.CategoryID != nil {
+		query = query.Where("category_id = ?", *q.CategoryID)
+	}
+	if q.Tag != "" {
+		query = query.Where("tags @> ?", pq.StringArray{q.Tag})
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var hits []*model.PostSearchHit
+	err := query.
+		Select("id, ? AS rank", score).
+		Order("rank desc, id desc").
+		Limit(q.Limit).
+		Offset(q.Offset).
+		Scan(&hits).Error
+	return hits, total, err
+}
+
+// ListSearchResults 读取命中文章用于展示，不读取正文
+func (r *PostRepository) ListSearchResults(ids []uint) ([]*model.PostSearchResult, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var results []*model.PostSearchResult
+	err := r.DB.Model(&model.Post{}).
+		Select("id, title, slug, excerpt, status, category_id, tags, published_at").
+		Where("id IN ?", ids).
+		Find(&results).Error
+	return results, err
+}
+
+// SuggestTitles 搜索框输入提示：标题包含输入内容、与输入相近或拼音匹配的已发布文章
+func (r *PostRepository) SuggestTitles(text string, limit int) ([]*model.Suggestion, error) {
+	pattern := "%" + escapeLike(text) + "%"
+	var suggestions []*model.Suggestion
+	err := r.DB.Model(&model.Post{}).
+		Select("'post' AS type, title AS text, id AS post_id, "+
+			"GREATEST(word_similarity(?, title), word_similarity(?, coalesce(search_pinyin, ''))) AS score", text, text).
+		Scopes(published).
+		Where("(title ILIKE ? OR search_pinyin ILIKE ? OR ? <% title)", pattern, pattern, text).
+		Order("score desc, id desc").
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
+
+// SuggestTags 搜索框输入提示：已发布文章中包含输入内容或与输入相近的标签
+func (r *PostRepository) SuggestTags(text string, limit int) ([]*model.Suggestion, error) {
+	pattern := "%" + escapeLike(text) + "%"
+	var suggestions []*model.Suggestion
+	err := r.DB.Raw(`SELECT 'tag' AS type, tag AS text, MAX(word_similarity(?, tag)) AS score
+		FROM blog.posts, unnest(tags) AS tag
+		WHERE deleted_at IS NULL AND status IN ? AND (tag ILIKE ? OR ? <% tag)
+		GROUP BY tag
+		ORDER BY score DESC, tag
+		LIMIT ?`, text, listedStatuses, pattern, text, limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"crist-blog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type PostRevisionRepository struct {
+	DB *gorm.DB
+}
+
+func NewPostRevisionRepository(db *gorm.DB) *PostRevisionRepository {
+	return &PostRevisionRepository{DB: db}
+}
+
+func (r *PostRevisionRepository) Create(revision *model.PostRevision) error {
+	return r.DB.Create(revision).Error
+}
+
+// ListByPostID 按时间倒序列出文章的版本，附带保存者用户名
+func (r *PostRevisionRepository) ListByPostID(postID uint) ([]*model.PostRevisionSummary, error) {
+	var revisions []*model.PostRevisionSummary
+	err := r.DB.Table("blog.post_revisions AS r").
+		Select("r.id, r.post_id, r.user_id, u.username, r.title, r.created_at").
+		Joins("LEFT JOIN admin.users u ON u.id = r.user_id").
+		Where("r.post_id = ?", postID).
+		Order("r.id DESC").
+		Scan(&revisions).Error
+	return revisions, err
+}
+
+// GetByID 读取文章的某个版本，版本不属于该文章时返回 gorm.ErrRecordNotFound
+func (r *PostRevisionRepository) GetByID(postID, id uint) (*model.PostRevision, error) {
+	var revision model.PostRevision
+	err := r.DB.Where("id = ? AND post_id = ?", id, postID).First(&revision).Error
+	return &revision, err
+}
+
+// Prune 只保留文章最新的 keep 个版本
+func (r *PostRevisionRepository) Prune(postID uint, keep int) error {
+	return r.DB.Where("post_id = ? AND id NOT IN (?)", postID,
+		r.DB.Model(&model.PostRevision{}).Select("id").Where("post_id = ?", postID).Order("id DESC").Limit(keep)).
+		Delete(&model.PostRevision{}).Error
+}
+
+// Latest 读取文章最新的版本
+func (r *PostRevisionRepository) Latest(postID uint) (*model.PostRevision, error) {
+	var revision model.PostRevision
+	err := r.DB.Where("post_id = ?", postID).Order("id DESC").First(&revision).Error
+	return &revision, err
+}
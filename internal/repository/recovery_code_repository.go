@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"crist-blog/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository struct {
+	DB *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{
+		DB: db,
+	}
+}
+
+// ReplaceForUser 删除用户原有的恢复码并写入新的一组
+func (r *RecoveryCodeRepository) ReplaceForUser(userID uuid.UUID, hashes []string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]model.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, model.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume 使用一个未使用的恢复码，成功时返回 true
+func (r *RecoveryCodeRepository) Consume(userID uuid.UUID, hash string) (bool, error) {
+	result := r.DB.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *RecoveryCodeRepository) CountUnused(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *RecoveryCodeRepository) DeleteByUserID(userID uuid.UUID) error {
+	return r.DB.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
@@ -36,12 +36,67 @@ func (r *RefreshTokenRepository) Revoke(id uuid.UUID) error {
 		Update("revoked", true).Error
 }
 
+// RevokeFamily 撤销同一令牌族中的所有刷新令牌
+func (r *RefreshTokenRepository) RevokeFamily(familyID uuid.UUID) error {
+	return r.DB.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked = false", familyID).
+		Update("revoked", true).Error
+}
+
+// Rotate 在同一事务中撤销旧令牌并写入新令牌
+// 旧令牌已被撤销（并发重放）时返回 false，且不会写入新令牌
+func (r *RefreshTokenRepository) Rotate(oldID uuid.UUID, next *model.RefreshToken) (bool, error) {
+	rotated := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND revoked = false", oldID).
+			Update("revoked", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+// RevokeUserFamily 撤销属于指定用户的令牌族，返回被撤销的行数
+func (r *RefreshTokenRepository) RevokeUserFamily(userID, familyID uuid.UUID) (int64, error) {
+	result := r.DB.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND family_id = ? AND revoked = false", userID, familyID).
+		Update("revoked", true)
+	return result.RowsAffected, result.Error
+}
+
 func (r *RefreshTokenRepository) RevokeAllByUserID(userID uuid.UUID) error {
 	return r.DB.Model(&model.RefreshToken{}).
 		Where("user_id = ? AND revoked = false", userID).
 		Update("revoked", true).Error
 }
 
+// RevokeAllByUserIDExcept 撤销用户除指定令牌族以外的全部刷新令牌
+func (r *RefreshTokenRepository) RevokeAllByUserIDExcept(userID, familyID uuid.UUID) error {
+	return r.DB.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked = false", userID, familyID).
+		Update("revoked", true).Error
+}
+
+// ListActiveByUserID 返回用户所有有效的刷新令牌，最近使用的在前
+// 轮换会撤销旧令牌，因此每个令牌族（会话）至多只有一条有效记录
+func (r *RefreshTokenRepository) ListActiveByUserID(userID uuid.UUID) ([]*model.RefreshToken, error) {
+	var tokens []*model.RefreshToken
+	err := r.DB.Where("user_id = ? AND revoked = false AND expires_at > ?", userID, time.Now()).
+		Order("created_at desc").
+		Find(&tokens).Error
+	return tokens, err
+}
+
 func (r *RefreshTokenRepository) CleanExpiredTokens() error {
 	return r.DB.Where("expires_at < ? OR revoked = true", time.Now()).Delete(&model.RefreshToken{}).Error
 }
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"crist-blog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	DB *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{
+		DB: db,
+	}
+}
+
+func (r *RoleRepository) ListRoles() ([]model.Role, error) {
+	var roles []model.Role
+	err := r.DB.Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *RoleRepository) ListRolePermissions() ([]model.RolePermission, error) {
+	var permissions []model.RolePermission
+	err := r.DB.Find(&permissions).Error
+	return permissions, err
+}
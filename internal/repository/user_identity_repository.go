@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"crist-blog/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepository struct {
+	DB *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) *UserIdentityRepository {
+	return &UserIdentityRepository{
+		DB: db,
+	}
+}
+
+func (r *UserIdentityRepository) Create(identity *model.UserIdentity) error {
+	return r.DB.Create(identity).Error
+}
+
+func (r *UserIdentityRepository) FindByProviderSubject(provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	if err := r.DB.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *UserIdentityRepository) ListByUserID(userID uuid.UUID) ([]*model.UserIdentity, error) {
+	var identities []*model.UserIdentity
+	err := r.DB.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+// Delete 删除属于指定用户的绑定，返回被删除的行数
+func (r *UserIdentityRepository) Delete(userID, id uuid.UUID) (int64, error) {
+	result := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&model.UserIdentity{})
+	return result.RowsAffected, result.Error
+}
+
+// CreateUserWithIdentity 在同一事务中创建用户及其第三方账号绑定
+func (r *UserIdentityRepository) CreateUserWithIdentity(user *model.User, identity *model.UserIdentity) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
@@ -2,10 +2,15 @@ package repository
 
 import (
 	"crist-blog/internal/model"
+	"errors"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var ErrInvitationUnavailable = errors.New("invitation already used or expired")
+
 type UserRepository struct {
 	DB *gorm.DB
 }
@@ -20,3 +25,202 @@ func (r *UserRepository) GetByName(name string) (*model.User, error) {
 	err := r.DB.Where("username = ?", name).First(&user).Error
 	return &user, err
 }
+
+func (r *UserRepository) GetByID(id uuid.UUID) (*model.User, error) {
+	var user model.User
+	err := r.DB.Where("id = ?", id).First(&user).Error
+	return &user, err
+}
+
+func (r *UserRepository) GetByEmail(email string) (*model.User, error) {
+	var user model.User
+	err := r.DB.Where("lower(email) = lower(?)", email).First(&user).Error
+	return &user, err
+}
+
+// ExistsByUsernameOrEmail 判断用户名或邮箱是否已被占用（包括已软删除的用户）
+func (r *UserRepository) ExistsByUsernameOrEmail(username, email string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&model.User{}).Unscoped().
+		Where("username = ? OR lower(email) = lower(?)", username, email).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *UserRepository) Create(user *model.User) error {
+	return r.DB.Create(user).Error
+}
+
+// CreateWithInvitation 在同一事务中核销邀请码并创建用户
+// 邀请码已被使用或已过期时返回 ErrInvitationUnavailable
+func (r *UserRepository) CreateWithInvitation(user *model.User, invitationID uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&model.Invitation{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", invitationID, time.Now()).
+			Updates(map[string]interface{}{"used_at": time.Now(), "used_by": user.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationUnavailable
+		}
+		return nil
+	})
+}
+
+func (r *UserRepository) MarkEmailVerified(id uuid.UUID) error {
+	return r.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Update("email_verified_at", time.Now()).Error
+}
+
+func (r *UserRepository) UpdatePassword(id uuid.UUID, passwordHash string) error {
+	return r.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password_hash":           passwordHash,
+			"password_reset_required": false,
+			"updated_at":              time.Now(),
+		}).Error
+}
+
+// UpdateTOTP 设置两步验证密钥和启用状态，并将已使用的时间步设为 lastStep
+// 启用时传入确认所用验证码的时间步，防止该验证码在有效期内被再次用于登录
+func (r *UserRepository) UpdateTOTP(id uuid.UUID, secret string, enabled bool, lastStep int64) error {
+	return r.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"totp_secret":    secret,
+			"totp_enabled":   enabled,
+			"totp_last_step": lastStep,
+			"updated_at":     time.Now(),
+		}).Error
+}
+
+// AdvanceTOTPStep 记录最近一次使用的时间步，时间步未前进（验证码被重放）时返回 false
+func (r *UserRepository) AdvanceTOTPStep(id uuid.UUID, step int64) (bool, error) {
+	result := r.DB.Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ExistsByUsername 判断用户名是否已被占用（包括已软删除的用户）
+func (r *UserRepository) ExistsByUsername(username string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&model.User{}).Unscoped().
+		Where("username = ?", username).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// UpdateProfile 更新个人资料字段，fields 的键为列名
+func (r *UserRepository) UpdateProfile(id uuid.UUID, fields map[string]interface{}) error {
+	fields["updated_at"] = time.Now()
+	return r.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(fields).Error
+}
+
+// UpdateEmail 修改邮箱，新邮箱已经过验证
+func (r *UserRepository) UpdateEmail(id uuid.UUID, email string) error {
+	now := time.Now()
+	return r.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "email_verified_at": now, "updated_at": now}).Error
+}
+
+// ExistsByEmail 判断邮箱是否已被占用（包括已软删除的用户）
+func (r *UserRepository) ExistsByEmail(email string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&model.User{}).Unscoped().
+		Where("lower(email) = lower(?)", email).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// IsActive 判断用户是否存在且未被停用或删除
+func (r *UserRepository) IsActive(id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Model(&model.User{}).
+		Where("id = ? AND disabled_at IS NULL", id).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetByIDUnscoped 按ID查找用户，包括已软删除的用户
+func (r *UserRepository) GetByIDUnscoped(id uuid.UUID) (*model.User, error) {
+	var user model.User
+	err := r.DB.Unscoped().Where("id = ?", id).First(&user).Error
+	return &user, err
+}
+
+// List 分页查询用户，按注册时间倒序，同时返回符合条件的总数
+func (r *UserRepository) List(filter model.UserFilter, offset, limit int) ([]*model.User, int64, error) {
+	query := r.DB.Model(&model.User{}).Unscoped()
+	switch filter.Status {
+	case model.UserStatusActive:
+		query = query.Where("deleted_at IS NULL AND disabled_at IS NULL")
+	case model.UserStatusDisabled:
+		query = query.Where("deleted_at IS NULL AND disabled_at IS NOT NULL")
+	case model.UserStatusDeleted:
+		query = query.Where("deleted_at IS NOT NULL")
+	default:
+		query = query.Where("deleted_at IS NULL")
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		query = query.Where("(username ILIKE ? OR email ILIKE ? OR nickname ILIKE ?)", pattern, pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []*model.User
+	err := query.Order("created_at desc").
+		Offset(offset).
+		Limit(limit).
+		Find(&users).Error
+	return users, total, err
+}
+
+// SetDisabled 停用或启用用户，disabledAt 为 nil 表示启用
+func (r *UserRepository) SetDisabled(id uuid.UUID, disabledAt *time.Time) error {
+	return r.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"disabled_at": disabledAt, "updated_at": time.Now()}).Error
+}
+
+// SetPasswordResetRequired 设置用户是否必须重置密码
+func (r *UserRepository) SetPasswordResetRequired(id uuid.UUID, required bool) error {
+	return r.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"password_reset_required": required, "updated_at": time.Now()}).Error
+}
+
+func (r *UserRepository) UpdateRole(id uuid.UUID, role string) error {
+	return r.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"role": role, "updated_at": time.Now()}).Error
+}
+
+// SoftDelete 软删除用户，返回受影响的行数
+func (r *UserRepository) SoftDelete(id uuid.UUID) (int64, error) {
+	result := r.DB.Where("id = ?", id).Delete(&model.User{})
+	return result.RowsAffected, result.Error
+}
+
+// Restore 恢复已软删除的用户，返回受影响的行数
+func (r *UserRepository) Restore(id uuid.UUID) (int64, error) {
+	result := r.DB.Unscoped().Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "updated_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
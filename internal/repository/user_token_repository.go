@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"crist-blog/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserTokenRepository struct {
+	DB *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) *UserTokenRepository {
+	return &UserTokenRepository{
+		DB: db,
+	}
+}
+
+func (r *UserTokenRepository) Create(token *model.UserToken) error {
+	return r.DB.Create(token).Error
+}
+
+// Consume 原子地将未使用且未过期的令牌标记为已使用并返回该令牌
+// 令牌不存在、已使用或已过期时返回 gorm.ErrRecordNotFound
+func (r *UserTokenRepository) Consume(purpose, hash string) (*model.UserToken, error) {
+	var token model.UserToken
+	now := time.Now()
+	result := r.DB.Model(&token).
+		Clauses(clause.Returning{}).
+		Where("purpose = ? AND token_hash = ? AND used_at IS NULL AND expires_at > ?", purpose, hash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &token, nil
+}
+
+// InvalidateByUserID 作废用户某一用途下所有未使用的令牌，发送新令牌前调用
+func (r *UserTokenRepository) InvalidateByUserID(userID uuid.UUID, purpose string) error {
+	return r.DB.Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
+
+// FindActive 查找用户某一用途下最新的未使用且未过期的令牌
+func (r *UserTokenRepository) FindActive(userID uuid.UUID, purpose string) (*model.UserToken, error) {
+	var token model.UserToken
+	err := r.DB.Where("user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", userID, purpose, time.Now()).
+		Order("created_at desc").
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
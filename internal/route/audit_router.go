@@ -0,0 +1,18 @@
+package route
+
+import (
+	"crist-blog/internal/handler"
+	"crist-blog/internal/middleware"
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetupAuditRouter(e *echo.Echo, auditHandler *handler.AuditHandler, authService *service.AuthService, rbacService *service.RBACService) {
+	audit := e.Group("/api/admin/audit-logs", middleware.AuthMiddleware(authService), middleware.RequirePermission(rbacService, model.PermAuditRead))
+	{
+		audit.GET("", auditHandler.List)
+		audit.GET("/export", auditHandler.Export)
+	}
+}
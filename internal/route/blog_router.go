@@ -3,6 +3,9 @@ package route
 import (
 	"bytes"
 	"crist-blog/internal/handler"
+	"crist-blog/internal/middleware"
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
 	"fmt"
 	"io"
 	"log"
@@ -12,18 +15,42 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-func SetupBlogRouter(e *echo.Echo, postHandler *handler.PostHandler) {
+func SetupBlogRouter(e *echo.Echo,
+	postHandler *handler.PostHandler,
+	authService *service.AuthService,
+	rbacService *service.RBACService) {
 	api := e.Group("/api")
 	api.GET("/proxy/image", proxyImage)
+	api.GET("/preview/:token", postHandler.Preview)
 	posts := api.Group("/posts")
-	posts.POST("/create", postHandler.CreatePost)
+	posts.GET("", postHandler.Query)
 	posts.GET("/getAllPosts", postHandler.ListToFrontend)
 	posts.GET("/get/:id", postHandler.GetBlogToViewers)
-	posts.PUT("/update/:id", postHandler.Update)
-	posts.DELETE("/delete/:id", postHandler.Delete)
+	posts.GET("/slug/:slug", postHandler.GetBySlug)
 	posts.GET("/hot", postHandler.GetHotPosts)
 	posts.GET("/latest", postHandler.GetLatestPosts)
-	posts.POST("/create", postHandler.CreatePost)
+	posts.POST("/:id/unlock", postHandler.Unlock)
+
+	// 写操作需要登录，修改和删除的归属检查在 handler 中完成
+	auth := posts.Group("", middleware.AuthMiddleware(authService))
+	auth.GET("/manage", postHandler.QueryManaged)
+	auth.POST("/bulk", postHandler.Bulk)
+	auth.POST("/create", postHandler.CreatePost, middleware.RequirePermission(rbacService, model.PermPostCreate))
+	auth.PUT("/update/:id", postHandler.Update)
+	auth.DELETE("/delete/:id", postHandler.Delete)
+	auth.GET("/:id/edit", postHandler.GetForEdit)
+	auth.GET("/:id/lock", postHandler.GetLock)
+	auth.POST("/:id/lock", postHandler.AcquireLock)
+	auth.PUT("/:id/lock", postHandler.HeartbeatLock)
+	auth.DELETE("/:id/lock", postHandler.ReleaseLock)
+	auth.GET("/:id/autosave", postHandler.GetAutosave)
+	auth.PUT("/:id/autosave", postHandler.Autosave)
+	auth.DELETE("/:id/autosave", postHandler.DiscardAutosave)
+	auth.POST("/:id/preview-links", postHandler.CreatePreviewLink)
+	auth.GET("/:id/revisions", postHandler.ListRevisions)
+	auth.GET("/:id/revisions/diff", postHandler.DiffRevisions)
+	auth.GET("/:id/revisions/:revision", postHandler.GetRevision)
+	auth.POST("/:id/revisions/:revision/restore", postHandler.RestoreRevision)
 }
 
 // proxyImage 处理图片代理请求
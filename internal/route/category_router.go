@@ -2,13 +2,25 @@ package route
 
 import (
 	"crist-blog/internal/handler"
+	"crist-blog/internal/middleware"
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
 
 	"github.com/labstack/echo/v4"
 )
 
-func SetupCategoryRouter(e *echo.Echo, categoryHandler *handler.CategoryHandler) {
+func SetupCategoryRouter(e *echo.Echo,
+	categoryHandler *handler.CategoryHandler,
+	authService *service.AuthService,
+	rbacService *service.RBACService) {
 	api := e.Group("/api")
 	category := api.Group("/category")
 	category.GET("/getAll", categoryHandler.ListAllCategories)
 
+	manage := category.Group("",
+		middleware.AuthMiddleware(authService),
+		middleware.RequirePermission(rbacService, model.PermCategoryManage))
+	manage.POST("/create", categoryHandler.Create)
+	manage.PUT("/update/:id", categoryHandler.Update)
+	manage.DELETE("/delete/:id", categoryHandler.Delete)
 }
@@ -0,0 +1,25 @@
+package route
+
+import (
+	"crist-blog/internal/handler"
+	"crist-blog/internal/middleware"
+	"crist-blog/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetupOAuthRouter(e *echo.Echo, oauthHandler *handler.OAuthHandler, authService *service.AuthService) {
+	oauth := e.Group("/api/oauth")
+	{
+		oauth.GET("/providers", oauthHandler.Providers)
+		oauth.GET("/:provider/login", oauthHandler.Login)
+		oauth.GET("/:provider/callback", oauthHandler.Callback)
+	}
+
+	// 第三方账号绑定管理
+	identities := e.Group("/api/me/identities", middleware.AuthMiddleware(authService), middleware.RejectPersonalAccessToken)
+	{
+		identities.GET("", oauthHandler.ListIdentities)
+		identities.DELETE("/:id", oauthHandler.Unlink)
+	}
+}
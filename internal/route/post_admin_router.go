@@ -0,0 +1,21 @@
+package route
+
+import (
+	"crist-blog/internal/handler"
+	"crist-blog/internal/middleware"
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetupPostAdminRouter(e *echo.Echo, postHandler *handler.PostHandler, authService *service.AuthService, rbacService *service.RBACService) {
+	trash := e.Group("/api/admin/posts/trash",
+		middleware.AuthMiddleware(authService),
+		middleware.RequirePermission(rbacService, model.PermPostDeleteAny))
+	{
+		trash.GET("", postHandler.ListTrash)
+		trash.POST("/:id/restore", postHandler.RestoreFromTrash)
+		trash.DELETE("/:id", postHandler.PurgeFromTrash)
+	}
+}
@@ -0,0 +1,21 @@
+package route
+
+import (
+	"crist-blog/internal/handler"
+	"crist-blog/internal/middleware"
+	"crist-blog/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetupProfileRouter(e *echo.Echo, profileHandler *handler.ProfileHandler, authService *service.AuthService) {
+	e.GET("/api/authors/:username", profileHandler.GetAuthor)
+	e.POST("/api/email/confirm", profileHandler.ConfirmEmail)
+
+	me := e.Group("/api/me", middleware.AuthMiddleware(authService))
+	{
+		me.GET("", profileHandler.GetMe)
+		// 修改邮箱属于账号安全操作，不允许使用个人访问令牌
+		me.PATCH("", profileHandler.UpdateMe, middleware.RejectPersonalAccessToken)
+	}
+}
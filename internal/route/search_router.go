@@ -0,0 +1,16 @@
+package route
+
+import (
+	"crist-blog/internal/handler"
+	"crist-blog/internal/middleware"
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetupSearchRouter(e *echo.Echo, searchHandler *handler.SearchHandler, authService *service.AuthService, rbacService *service.RBACService) {
+	e.GET("/api/posts/search", searchHandler.Search)
+	e.GET("/api/posts/suggest", searchHandler.Suggest)
+	e.POST("/api/admin/search/reindex", searchHandler.Reindex, middleware.AuthMiddleware(authService), middleware.RequirePermission(rbacService, model.PermPostEditAny))
+}
@@ -0,0 +1,29 @@
+package route
+
+import (
+	"crist-blog/internal/handler"
+	"crist-blog/internal/middleware"
+	"crist-blog/internal/model"
+	"crist-blog/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetupUserAdminRouter(e *echo.Echo, userAdminHandler *handler.UserAdminHandler, authService *service.AuthService, rbacService *service.RBACService) {
+	users := e.Group("/api/admin/users",
+		middleware.AuthMiddleware(authService),
+		middleware.RejectPersonalAccessToken,
+		middleware.RequirePermission(rbacService, model.PermUserManage))
+	{
+		users.GET("", userAdminHandler.List)
+		users.POST("", userAdminHandler.Create)
+		users.GET("/:id", userAdminHandler.Get)
+		users.DELETE("/:id", userAdminHandler.Delete)
+		users.POST("/:id/restore", userAdminHandler.Restore)
+		users.POST("/:id/disable", userAdminHandler.Disable)
+		users.POST("/:id/enable", userAdminHandler.Enable)
+		users.POST("/:id/unlock", userAdminHandler.Unlock)
+		users.POST("/:id/force-password-reset", userAdminHandler.ForcePasswordReset)
+		users.PUT("/:id/role", userAdminHandler.SetRole)
+	}
+}
@@ -3,6 +3,7 @@ package route
 import (
 	"crist-blog/internal/handler"
 	"crist-blog/internal/middleware"
+	"crist-blog/internal/model"
 	"crist-blog/internal/service"
 	"net/http"
 
@@ -11,13 +12,24 @@ import (
 
 func SetupUserRoutes(e *echo.Echo,
 	userHandler *handler.UserHandler,
-	authService *service.AuthService) {
+	registrationHandler *handler.RegistrationHandler,
+	passwordHandler *handler.PasswordHandler,
+	twoFactorHandler *handler.TwoFactorHandler,
+	patHandler *handler.PersonalAccessTokenHandler,
+	authService *service.AuthService,
+	rbacService *service.RBACService) {
 
 	admin := e.Group("/api")
 	admin.GET("/check", func(c echo.Context) error {
 		return c.String(http.StatusOK, "Hello, Admin!")
 	})
 	admin.POST("/login", userHandler.Login)
+	admin.POST("/login/2fa", userHandler.LoginTwoFactor)
+	admin.POST("/register", registrationHandler.Register)
+	admin.POST("/verify-email", registrationHandler.VerifyEmail)
+	admin.POST("/verify-email/resend", registrationHandler.ResendVerification)
+	admin.POST("/password/forgot", passwordHandler.ForgotPassword)
+	admin.POST("/password/reset", passwordHandler.ResetPassword)
 
 	auth := e.Group("/api")
 	auth.Use(middleware.AuthMiddleware(authService))
@@ -26,5 +38,31 @@ func SetupUserRoutes(e *echo.Echo,
 		auth.GET("/user", func(c echo.Context) error {
 			return c.JSON(http.StatusOK, c.Get("user_id"))
 		})
+		auth.POST("/logout", userHandler.Logout)
+		auth.PUT("/password", passwordHandler.ChangePassword, middleware.RejectPersonalAccessToken)
+
+		// 账号安全相关接口只允许交互式登录，不能用个人访问令牌操作
+		interactive := auth.Group("", middleware.RejectPersonalAccessToken)
+		interactive.GET("/sessions", userHandler.ListSessions)
+		interactive.DELETE("/sessions/others", userHandler.RevokeOtherSessions)
+		interactive.DELETE("/sessions/:id", userHandler.RevokeSession)
+
+		interactive.GET("/tokens", patHandler.List)
+		interactive.POST("/tokens", patHandler.Create)
+		interactive.DELETE("/tokens/:id", patHandler.Revoke)
+
+		interactive.POST("/2fa/setup", twoFactorHandler.Setup)
+		interactive.POST("/2fa/enable", twoFactorHandler.Enable)
+		interactive.POST("/2fa/disable", twoFactorHandler.Disable)
+		interactive.POST("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+
+		invite := middleware.RequirePermission(rbacService, model.PermUserInvite)
+		auth.POST("/invitations", registrationHandler.CreateInvitation, invite)
+		auth.GET("/invitations", registrationHandler.ListInvitations, invite)
+
+		manage := auth.Group("/admin/users",
+			middleware.RejectPersonalAccessToken,
+			middleware.RequirePermission(rbacService, model.PermUserManage))
+		manage.DELETE("/:id/2fa", twoFactorHandler.AdminReset)
 	}
 }
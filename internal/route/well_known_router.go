@@ -0,0 +1,12 @@
+package route
+
+import (
+	"crist-blog/internal/handler"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetupWellKnownRouter(e *echo.Echo, jwksHandler *handler.JWKSHandler) {
+	wellKnown := e.Group("/.well-known")
+	wellKnown.GET("/jwks.json", jwksHandler.JWKS)
+}
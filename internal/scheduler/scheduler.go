@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job 是按固定间隔执行的后台任务
+// 多实例部署时每个实例都会执行，任务自身需要通过数据库锁等方式避免重复处理
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler 在服务进程内运行后台任务，每个任务一个协程
+type Scheduler struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add 注册任务，必须在 Start 之前调用
+// 间隔必须大于 0，否则拒绝注册并返回错误（time.NewTicker 遇到非正间隔会 panic）
+func (s *Scheduler) Add(job Job) error {
+	if job.Interval <= 0 {
+		return fmt.Errorf("scheduler: job %s has invalid interval %s", job.Name, job.Interval)
+	}
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+// Start 启动全部任务，启动时立即执行一次，之后按间隔执行；ctx 取消后任务退出
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Wait 等待全部任务退出
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		s.run(ctx, job)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run 执行一次任务，任务出错或 panic 只记录日志，不影响下一次执行
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("scheduler: job %s panicked: %v", job.Name, r)
+		}
+	}()
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("scheduler: job %s failed: %v", job.Name, err)
+	}
+}
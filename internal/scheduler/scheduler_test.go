@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAddRejectsNonPositiveInterval(t *testing.T) {
+	s := New()
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if err := s.Add(Job{Name: "bad", Interval: interval, Run: func(context.Context) error { return nil }}); err == nil {
+			t.Fatalf("interval %s: expected error", interval)
+		}
+	}
+	if len(s.jobs) != 0 {
+		t.Fatalf("%d jobs registered, want 0", len(s.jobs))
+	}
+}
+
+func TestWaitReturnsAfterCancel(t *testing.T) {
+	s := New()
+	ran := make(chan struct{}, 1)
+	err := s.Add(Job{Name: "tick", Interval: time.Hour, Run: func(context.Context) error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	// 启动时立即执行一次
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("job did not run on start")
+	}
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		s.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after cancel")
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actor 发起操作的主体及其请求信息，写入审计日志
+type Actor struct {
+	UserID    uuid.UUID // 未登录时为 uuid.Nil
+	Username  string
+	IP        string
+	UserAgent string
+}
+
+type AuditService struct {
+	auditRepo *repository.AuditLogRepository
+}
+
+func NewAuditService(auditRepo *repository.AuditLogRepository) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+	}
+}
+
+// Record 写入一条审计日志，err 为 nil 记为成功，否则记为失败并保存错误信息
+// 审计写入失败只打日志，不影响业务操作本身
+func (s *AuditService) Record(actor Actor, action, targetType, targetID string, err error) {
+	entry := &model.AuditLog{
+		ActorName:  actor.Username,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IPAddress:  actor.IP,
+		UserAgent:  actor.UserAgent,
+		Outcome:    model.AuditSuccess,
+		CreatedAt:  time.Now(),
+	}
+	if actor.UserID != uuid.Nil {
+		entry.ActorID = &actor.UserID
+	}
+	if err != nil {
+		entry.Outcome = model.AuditFailure
+		entry.Detail = err.Error()
+	}
+	if err := s.auditRepo.Create(entry); err != nil {
+		log.Printf("warning: failed to write audit log %s: %v", action, err)
+	}
+}
+
+func (s *AuditService) List(filter model.AuditLogFilter, page, pageSize int) ([]*model.AuditLog, int64, error) {
+	return s.auditRepo.List(filter, (page-1)*pageSize, pageSize)
+}
+
+func (s *AuditService) Each(filter model.AuditLogFilter, fn func(*model.AuditLog) error) error {
+	return s.auditRepo.Each(filter, fn)
+}
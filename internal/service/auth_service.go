@@ -1,9 +1,13 @@
 package service
 
 import (
+	"crist-blog/internal/denylist"
+	"crist-blog/internal/keyset"
 	"crist-blog/internal/model"
 	"crist-blog/internal/repository"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"log"
@@ -11,7 +15,6 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 const (
@@ -20,40 +23,79 @@ const (
 	refreshTokenLength = 64
 )
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, please login again")
+)
+
+var (
+	ErrSessionNotFound            = errors.New("session not found")
+	ErrInvalidPersonalAccessToken = errors.New("invalid personal access token")
+	ErrAccountDisabled            = errors.New("账号已被停用")
+)
+
+// patTouchInterval 个人访问令牌最近使用时间的更新间隔，避免每个请求都写库
+const patTouchInterval = time.Minute
+
+// AuthConfig 认证相关的可配置项
+type AuthConfig struct {
+	// RefreshTokenSecret 计算刷新令牌 HMAC 哈希的密钥
+	RefreshTokenSecret []byte
+	// MaxSessionsPerUser 每个用户允许同时存在的会话数，超出时淘汰最久未使用的会话，0 表示不限制
+	MaxSessionsPerUser int
+}
+
 type AuthService struct {
 	userRepo           *repository.UserRepository
 	refreshTokenRepo   *repository.RefreshTokenRepository
-	jwtSecret          string
+	patRepo            *repository.PersonalAccessTokenRepository
+	keys               *keyset.KeySet
+	denylist           denylist.Store
+	audit              *AuditService
+	config             AuthConfig
 	refreshTokenLength int
 }
 
 func NewAuthService(
 	userRepo *repository.UserRepository,
 	refreshTokenRepo *repository.RefreshTokenRepository,
-	jwtSecret string) *AuthService {
+	patRepo *repository.PersonalAccessTokenRepository,
+	keys *keyset.KeySet,
+	denylist denylist.Store,
+	audit *AuditService,
+	config AuthConfig) *AuthService {
 	return &AuthService{
 		userRepo:           userRepo,
 		refreshTokenRepo:   refreshTokenRepo,
-		jwtSecret:          jwtSecret,
+		patRepo:            patRepo,
+		keys:               keys,
+		denylist:           denylist,
+		audit:              audit,
+		config:             config,
 		refreshTokenLength: refreshTokenLength}
 }
 
 // generateAccessToken 生成用于用户认证的访问令牌
 // 参数:
 //   - userID: 用户唯一标识符
+//   - sessionID: 会话ID，即刷新令牌族ID
 //
 // 返回值:
 //   - string: 生成的JWT访问令牌
 //   - error: 生成过程中可能出现的错误
-func (s *AuthService) generateAccessToken(userID uuid.UUID) (string, error) {
-	// 创建一个新的JWT令牌，使用HS256签名方法
-	// 并设置自定义声明：用户ID和过期时间
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID.String(),                          // 将用户ID转换为字符串并存储在声明中
-		"exp":     time.Now().Add(AccessTokenExpire).Unix(), // 设置令牌过期时间
+func (s *AuthService) generateAccessToken(userID, sessionID uuid.UUID) (string, error) {
+	now := time.Now()
+	// 设置自定义声明：令牌ID、用户ID、会话ID、签发时间和过期时间
+	// 使用密钥集中当前的签名密钥签名，头部携带 kid 以便轮换
+	return s.keys.Sign(jwt.MapClaims{
+		"typ":     "access",                          // 令牌类型，区别于两步登录的中间令牌等
+		"jti":     uuid.New().String(),               // 令牌ID，注销时加入黑名单
+		"user_id": userID.String(),                   // 将用户ID转换为字符串并存储在声明中
+		"sid":     sessionID.String(),                // 会话ID
+		"iat":     now.Unix(),                        // 签发时间
+		"exp":     now.Add(AccessTokenExpire).Unix(), // 设置令牌过期时间
 	})
-	// 使用JWT密钥对令牌进行签名，并返回签名字符串
-	return token.SignedString([]byte(s.jwtSecret))
 }
 
 // generateRandomToken 是一个方法，属于 AuthService 结构体
@@ -71,7 +113,35 @@ func (s *AuthService) generateRandomToken() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
+// hashRefreshToken 使用 HMAC-SHA256 计算刷新令牌的确定性哈希
+// 与 bcrypt 不同，相同令牌总是得到相同哈希，因此可以直接按哈希查库；
+// 密钥只存在于服务端，数据库泄露也无法离线伪造令牌
+func (s *AuthService) hashRefreshToken(token string) string {
+	mac := hmac.New(sha256.New, s.config.RefreshTokenSecret)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newRefreshToken 生成随机刷新令牌及其数据库记录（尚未保存）
+func (s *AuthService) newRefreshToken(userID, familyID uuid.UUID, userAgent, ip string) (string, *model.RefreshToken, error) {
+	token, err := s.generateRandomToken()
+	if err != nil {
+		return "", nil, err
+	}
+	rt := &model.RefreshToken{
+		UserID:    userID,                             // 用户ID
+		FamilyID:  familyID,                           // 令牌族ID
+		TokenHash: s.hashRefreshToken(token),          // 哈希后的令牌
+		UserAgent: userAgent,                          // 用户代理
+		IPAddress: ip,                                 // IP地址
+		ExpiresAt: time.Now().Add(RefreshTokenExpire), // 过期时间
+		Revoked:   false,                              // 未被撤销
+	}
+	return token, rt, nil
+}
+
 // GenerateTokens 为用户生成访问令牌和刷新令牌
+// 每次登录都会开启一个新的令牌族，即一个新的会话
 // 参数:
 //   - user: 用户模型指针，包含用户信息
 //   - userAgent: 用户代理字符串，表示客户端类型
@@ -82,67 +152,224 @@ func (s *AuthService) generateRandomToken() (string, error) {
 //   - refreshToken: 刷新令牌字符串
 //   - err: 错误信息，如果生成过程中出现错误
 func (s *AuthService) GenerateTokens(user *model.User, userAgent, ip string) (accessToken, refreshToken string, err error) {
+	sessionID := uuid.New()
+	// 签发令牌即登录成功，密码、两步验证和第三方登录都经过这里
+	defer func() {
+		actor := Actor{UserID: user.ID, Username: user.Username, IP: ip, UserAgent: userAgent}
+		s.audit.Record(actor, model.AuditLogin, "session", sessionID.String(), err)
+	}()
+	if user.DisabledAt != nil {
+		return "", "", ErrAccountDisabled
+	}
 	// 生成访问令牌，使用用户ID作为参数，仅进行校验
-	accessToken, err = s.generateAccessToken(user.ID)
+	accessToken, err = s.generateAccessToken(user.ID, sessionID)
 	if err != nil {
 		return "", "", err
 	}
 	// 生成随机刷新令牌，长期存储
-	refreshToken, err = s.generateRandomToken()
+	refreshToken, rt, err := s.newRefreshToken(user.ID, sessionID, userAgent, ip)
 	if err != nil {
 		return "", "", err
 	}
+	// 超出会话数上限时淘汰最久未使用的会话，其它设备上的会话不受影响
+	if err := s.enforceSessionLimit(user.ID); err != nil {
+		log.Printf("warning: failed to enforce session limit for user %s: %v", user.ID, err)
+	}
 
-	// 使用bcrypt算法对刷新令牌进行哈希处理，增强安全性
-	tokenHash, err := bcrypt.GenerateFromPassword([]byte(refreshToken), bcrypt.DefaultCost)
+	// 将新的刷新令牌保存到数据库
+	err = s.refreshTokenRepo.CreateRefreshToken(rt)
 	if err != nil {
 		return "", "", err
 	}
-	// 撤销该用户之前的所有刷新令牌
-	_ = s.refreshTokenRepo.RevokeAllByUserID(user.ID)
 
-	// 创建新的刷新令牌记录
-	rt := &model.RefreshToken{
-		UserID:    user.ID,                            // 用户ID
-		TokenHash: string(tokenHash),                  // 哈希后的令牌
-		UserAgent: userAgent,                          // 用户代理
-		IPAddress: ip,                                 // IP地址
-		ExpiresAt: time.Now().Add(RefreshTokenExpire), // 过期时间
-		Revoked:   false,                              // 未被撤销
+	// 返回生成的访问令牌和刷新令牌
+	return accessToken, refreshToken, nil
+}
+
+// RefreshAccessToken 使用刷新令牌换取新的访问令牌，同时轮换刷新令牌
+// 已撤销的刷新令牌再次出现说明它可能已被窃取，此时撤销整个令牌族，
+// 合法用户和攻击者都必须重新登录
+func (s *AuthService) RefreshAccessToken(refreshTokenStr, userAgent, ip string) (newAccessToken, newRefreshToken string, err error) {
+	actor := Actor{IP: ip, UserAgent: userAgent}
+	var sessionID string
+	defer func() {
+		s.audit.Record(actor, model.AuditRefresh, "session", sessionID, err)
+	}()
+
+	rt, err := s.refreshTokenRepo.FindByTokenHash(s.hashRefreshToken(refreshTokenStr))
+	if err != nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+	actor.UserID = rt.UserID
+	sessionID = rt.FamilyID.String()
+	if rt.Revoked {
+		s.revokeFamilyOnReuse(actor, rt)
+		return "", "", ErrRefreshTokenReused
+	}
+	if time.Now().After(rt.ExpiresAt) {
+		return "", "", ErrRefreshTokenExpired
+	}
+	if !s.IsUserActive(rt.UserID) {
+		if err := s.refreshTokenRepo.RevokeFamily(rt.FamilyID); err != nil {
+			log.Printf("warning: failed to revoke refresh token family %s: %v", rt.FamilyID, err)
+		}
+		return "", "", ErrAccountDisabled
 	}
 
-	// 将新的刷新令牌保存到数据库
-	err = s.refreshTokenRepo.CreateRefreshToken(rt)
+	newRefreshToken, next, err := s.newRefreshToken(rt.UserID, rt.FamilyID, userAgent, ip)
+	if err != nil {
+		return "", "", err
+	}
+	rotated, err := s.refreshTokenRepo.Rotate(rt.ID, next)
 	if err != nil {
 		return "", "", err
 	}
+	if !rotated {
+		// 并发请求抢先轮换了同一令牌，同样视为重放
+		s.revokeFamilyOnReuse(actor, rt)
+		return "", "", ErrRefreshTokenReused
+	}
 
-	// 返回生成的访问令牌和刷新令牌
-	return accessToken, refreshToken, nil
+	newAccessToken, err = s.generateAccessToken(rt.UserID, rt.FamilyID)
+	if err != nil {
+		return "", "", errors.New("failed to generate access token")
+	}
+	return newAccessToken, newRefreshToken, nil
+}
+
+func (s *AuthService) revokeFamilyOnReuse(actor Actor, rt *model.RefreshToken) {
+	log.Printf("warning: refresh token %s reused, revoking family %s", rt.ID, rt.FamilyID)
+	err := s.refreshTokenRepo.RevokeFamily(rt.FamilyID)
+	if err != nil {
+		log.Printf("warning: failed to revoke refresh token family %s: %v", rt.FamilyID, err)
+	}
+	s.audit.Record(actor, model.AuditRefreshReuse, "session", rt.FamilyID.String(), err)
+}
+
+// enforceSessionLimit 为即将创建的新会话腾出位置
+func (s *AuthService) enforceSessionLimit(userID uuid.UUID) error {
+	if s.config.MaxSessionsPerUser <= 0 {
+		return nil
+	}
+	active, err := s.refreshTokenRepo.ListActiveByUserID(userID)
+	if err != nil {
+		return err
+	}
+	// active 按最近使用时间倒序排列，保留前 MaxSessionsPerUser-1 个
+	for i := s.config.MaxSessionsPerUser - 1; i < len(active); i++ {
+		if _, err := s.refreshTokenRepo.RevokeUserFamily(userID, active[i].FamilyID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListSessions 列出用户当前所有有效会话，currentSessionID 对应的会话会被标记为当前会话
+func (s *AuthService) ListSessions(userID, currentSessionID uuid.UUID) ([]*model.Session, error) {
+	active, err := s.refreshTokenRepo.ListActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*model.Session, 0, len(active))
+	for _, rt := range active {
+		sessions = append(sessions, &model.Session{
+			ID:           rt.FamilyID,
+			UserAgent:    rt.UserAgent,
+			IPAddress:    rt.IPAddress,
+			LastActiveAt: rt.CreatedAt,
+			ExpiresAt:    rt.ExpiresAt,
+			Current:      rt.FamilyID == currentSessionID,
+		})
+	}
+	return sessions, nil
+}
+
+// RevokeSession 撤销用户的某个会话
+func (s *AuthService) RevokeSession(actor Actor, sessionID uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditSessionRevoke, "session", sessionID.String(), err)
+	}()
+	revoked, err := s.refreshTokenRepo.RevokeUserFamily(actor.UserID, sessionID)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
 }
 
-func (s *AuthService) RefreshAccessToken(refreshTokenStr string) (newAccessToken string, err error) {
-	var rt *model.RefreshToken
-	rt, err = s.refreshTokenRepo.FindByTokenHash(refreshTokenStr)
-	if rt == nil {
-		return "", errors.New("invalid refresh token")
+// RevokeOtherSessions 撤销除当前会话以外的所有会话
+func (s *AuthService) RevokeOtherSessions(actor Actor, currentSessionID uuid.UUID) error {
+	err := s.refreshTokenRepo.RevokeAllByUserIDExcept(actor.UserID, currentSessionID)
+	s.audit.Record(actor, model.AuditSessionRevokeOthers, "session", currentSessionID.String(), err)
+	return err
+}
+
+// Logout 注销当前会话：撤销会话对应的刷新令牌，并将访问令牌加入黑名单直到其自然过期
+func (s *AuthService) Logout(actor Actor, sessionID uuid.UUID, jti string, accessTokenExpiresAt time.Time) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditLogout, "session", sessionID.String(), err)
+	}()
+	if sessionID != uuid.Nil {
+		if _, err := s.refreshTokenRepo.RevokeUserFamily(actor.UserID, sessionID); err != nil {
+			return err
+		}
+	}
+	if jti == "" {
+		return nil
 	}
+	return s.denylist.Add(jti, time.Until(accessTokenExpiresAt))
+}
 
-	newAccessToken, err = s.generateAccessToken(rt.UserID)
+// IsAccessTokenRevoked 判断访问令牌是否已通过注销作废
+func (s *AuthService) IsAccessTokenRevoked(jti string) bool {
+	revoked, err := s.denylist.Contains(jti)
 	if err != nil {
-		return "", errors.New("failed to generate access token")
+		// 黑名单存储不可用时拒绝请求，避免已注销的令牌继续生效
+		log.Printf("warning: failed to check access token denylist: %v", err)
+		return true
 	}
-	if err := s.refreshTokenRepo.Revoke(rt.ID); err != nil {
-		log.Printf("warning: failed to revoke refresh token %s: %v", rt.ID, err)
+	return revoked
+}
+
+// IsUserActive 判断用户是否可以继续访问，已停用或已删除的用户立即失去访问权限
+func (s *AuthService) IsUserActive(userID uuid.UUID) bool {
+	active, err := s.userRepo.IsActive(userID)
+	if err != nil {
+		// 与黑名单一致，无法确认时拒绝请求
+		log.Printf("warning: failed to check status of user %s: %v", userID, err)
+		return false
 	}
+	return active
+}
 
-	return newAccessToken, nil
+// AuthenticatePersonalAccessToken 校验个人访问令牌，通过后返回令牌记录
+func (s *AuthService) AuthenticatePersonalAccessToken(token string) (*model.PersonalAccessToken, error) {
+	pat, err := s.patRepo.FindByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+	now := time.Now()
+	if pat.RevokedAt != nil || (pat.ExpiresAt != nil && now.After(*pat.ExpiresAt)) {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+	if !s.IsUserActive(pat.UserID) {
+		return nil, ErrAccountDisabled
+	}
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) > patTouchInterval {
+		if err := s.patRepo.TouchLastUsed(pat.ID, now); err != nil {
+			log.Printf("warning: failed to update last used time of token %s: %v", pat.ID, err)
+		}
+	}
+	return pat, nil
 }
 
 func (s *AuthService) GetTheRefreshTokenExpired() time.Duration {
 	return RefreshTokenExpire
 }
 
-func (s *AuthService) JwtSecret() string {
-	return s.jwtSecret
+// Keys 返回用于签发和验证访问令牌的密钥集
+func (s *AuthService) Keys() *keyset.KeySet {
+	return s.keys
 }
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// issueTestTokens 为用户开始一个新会话
+func issueTestTokens(t *testing.T, svc *AuthService, user *model.User) (accessToken, refreshToken string) {
+	t.Helper()
+	accessToken, refreshToken, err := svc.GenerateTokens(user, "test-agent", "1.2.3.4")
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	return accessToken, refreshToken
+}
+
+// activeRefreshTokens 返回用户未撤销的刷新令牌数
+func activeRefreshTokens(t *testing.T, db *gorm.DB, user *model.User) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked = false", user.ID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// sessionOf 返回访问令牌中的会话 ID（即刷新令牌族 ID）
+func sessionOf(t *testing.T, svc *AuthService, user *model.User, accessToken string) string {
+	t.Helper()
+	keys := svc.Keys()
+	token, err := jwt.Parse(accessToken, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods()))
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["typ"] != "access" || claims["user_id"] != user.ID.String() {
+		t.Fatalf("unexpected access token claims %v", claims)
+	}
+	sid, _ := claims["sid"].(string)
+	return sid
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestAuthService(t, db, AuthConfig{})
+	user := createTestUser(t, db, "alice")
+	access, refresh := issueTestTokens(t, svc, user)
+
+	newAccess, newRefresh, err := svc.RefreshAccessToken(refresh, "test-agent", "1.2.3.4")
+	if err != nil {
+		t.Fatalf("RefreshAccessToken: %v", err)
+	}
+	if newRefresh == refresh {
+		t.Fatal("refresh token was not rotated")
+	}
+	// 轮换后仍属于同一个会话
+	if sessionOf(t, svc, user, newAccess) != sessionOf(t, svc, user, access) {
+		t.Fatal("rotated token should keep the session id")
+	}
+	if n := activeRefreshTokens(t, db, user); n != 1 {
+		t.Fatalf("%d active refresh tokens, want 1", n)
+	}
+
+	if _, _, err := svc.RefreshAccessToken(newRefresh, "test-agent", "1.2.3.4"); err != nil {
+		t.Fatalf("second rotation: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestAuthService(t, db, AuthConfig{})
+	user := createTestUser(t, db, "alice")
+	_, stolen := issueTestTokens(t, svc, user)
+	_, otherSession := issueTestTokens(t, svc, user)
+
+	_, current, err := svc.RefreshAccessToken(stolen, "test-agent", "1.2.3.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 已轮换的旧令牌再次出现，整个令牌族都被撤销
+	if _, _, err := svc.RefreshAccessToken(stolen, "attacker", "6.6.6.6"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused token: got %v, want ErrRefreshTokenReused", err)
+	}
+	if _, _, err := svc.RefreshAccessToken(current, "test-agent", "1.2.3.4"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("token of revoked family: got %v, want ErrRefreshTokenReused", err)
+	}
+	// 其它会话不受影响
+	if _, _, err := svc.RefreshAccessToken(otherSession, "test-agent", "1.2.3.4"); err != nil {
+		t.Fatalf("other session: %v", err)
+	}
+	if !slices.Contains(auditActions(t, db), model.AuditRefreshReuse) {
+		t.Fatal("reuse was not audited")
+	}
+}
+
+func TestRefreshRejectsInvalidAndExpiredTokens(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestAuthService(t, db, AuthConfig{})
+	user := createTestUser(t, db, "alice")
+	if _, _, err := svc.RefreshAccessToken("unknown", "test-agent", "1.2.3.4"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("unknown token: got %v, want ErrInvalidRefreshToken", err)
+	}
+
+	_, refresh := issueTestTokens(t, svc, user)
+	if err := db.Model(&model.RefreshToken{}).Where("user_id = ?", user.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.RefreshAccessToken(refresh, "test-agent", "1.2.3.4"); !errors.Is(err, ErrRefreshTokenExpired) {
+		t.Fatalf("expired token: got %v, want ErrRefreshTokenExpired", err)
+	}
+}
+
+func TestRefreshRejectsDisabledUser(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestAuthService(t, db, AuthConfig{})
+	user := createTestUser(t, db, "alice")
+	_, refresh := issueTestTokens(t, svc, user)
+	if err := db.Model(user).Update("disabled_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.RefreshAccessToken(refresh, "test-agent", "1.2.3.4"); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("got %v, want ErrAccountDisabled", err)
+	}
+	if n := activeRefreshTokens(t, db, user); n != 0 {
+		t.Fatalf("%d active refresh tokens, want 0", n)
+	}
+}
+
+func TestSessionLimitEvictsOldestSession(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestAuthService(t, db, AuthConfig{MaxSessionsPerUser: 2})
+	user := createTestUser(t, db, "alice")
+	// 会话按创建时间排序，间隔几毫秒避免时间相同
+	_, oldest := issueTestTokens(t, svc, user)
+	time.Sleep(5 * time.Millisecond)
+	issueTestTokens(t, svc, user)
+	time.Sleep(5 * time.Millisecond)
+	issueTestTokens(t, svc, user)
+	if n := activeRefreshTokens(t, db, user); n != 2 {
+		t.Fatalf("%d active sessions, want 2", n)
+	}
+	if _, _, err := svc.RefreshAccessToken(oldest, "test-agent", "1.2.3.4"); err == nil {
+		t.Fatal("evicted session should not be refreshable")
+	}
+}
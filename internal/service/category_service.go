@@ -3,17 +3,23 @@ package service
 import (
 	"crist-blog/internal/model"
 	"crist-blog/internal/repository"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var ErrCategoryInUse = errors.New("分类下仍有文章，无法删除")
+
 type CategoryService struct {
 	CategoryRepo *repository.CategoryRepository
+	audit        *AuditService
 }
 
-func NewCategoryService(categoryRepo *repository.CategoryRepository) *CategoryService {
+func NewCategoryService(categoryRepo *repository.CategoryRepository, audit *AuditService) *CategoryService {
 	return &CategoryService{
 		CategoryRepo: categoryRepo,
+		audit:        audit,
 	}
 }
 
@@ -24,3 +30,40 @@ func (s *CategoryService) GetNameByID(id uuid.UUID) (string, error) {
 func (s *CategoryService) ListAllCategories() ([]model.Category, error) {
 	return s.CategoryRepo.ListAllCategories()
 }
+
+func (s *CategoryService) Create(actor Actor, category *model.Category) error {
+	category.ID = uuid.New()
+	category.CreatedAt = time.Now()
+	err := s.CategoryRepo.Create(category)
+	s.audit.Record(actor, model.AuditCategoryCreate, "category", category.ID.String(), err)
+	return err
+}
+
+func (s *CategoryService) Update(actor Actor, category *model.Category) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditCategoryUpdate, "category", category.ID.String(), err)
+	}()
+	existing, err := s.CategoryRepo.GetByID(category.ID)
+	if err != nil {
+		return err
+	}
+	existing.Name = category.Name
+	existing.Slug = category.Slug
+	existing.Description = category.Description
+	return s.CategoryRepo.Update(existing)
+}
+
+// Delete 删除分类，分类下仍有文章时拒绝删除
+func (s *CategoryService) Delete(actor Actor, id uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditCategoryDelete, "category", id.String(), err)
+	}()
+	count, err := s.CategoryRepo.CountPosts(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryInUse
+	}
+	return s.CategoryRepo.Delete(id)
+}
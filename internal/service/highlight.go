@@ -0,0 +1,108 @@
+package service
+
+import (
+	"crist-blog/internal/tokenizer"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// snippetRadius 片段在第一个命中词前后各保留的字符数
+const snippetRadius = 40
+
+const (
+	// wordBreak 插入分词片段之间的零宽空格，PostgreSQL 的解析器将其视为词的分隔，生成片段后去掉
+	wordBreak = "\u200b"
+	// markStart、markStop 是 ts_headline 标记命中词所用的私用区字符，转义 HTML 后再替换为 <mark>
+	markStart = "\ue000"
+	markStop  = "\ue001"
+)
+
+// headlineOptions ts_headline 的参数，每个结果最多两段、每段 15-35 个词
+var headlineOptions = "StartSel=" + markStart + ", StopSel=" + markStop +
+	`, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
+
+var headlineCleaner = strings.NewReplacer(wordBreak, "", markStart, "", markStop, "")
+
+// headlineDocument 生成保存在 search_document 中的文档：按分词结果在词之间插入 wordBreak
+// PostgreSQL 默认解析器把连续的汉字当作一个词，不切分就无法在中文正文中定位检索词
+func headlineDocument(t tokenizer.Tokenizer, text string) string {
+	return strings.Join(t.Segment(headlineCleaner.Replace(text)), wordBreak)
+}
+
+// renderHeadline 把 ts_headline 的结果转为展示用的 HTML：去掉分词标记、转义，再把命中标记替换为 <mark>
+func renderHeadline(headline string) string {
+	text := html.EscapeString(strings.ReplaceAll(headline, wordBreak, ""))
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(text)
+}
+
+// markTerms 标记 text 中与检索词匹配（不区分大小写）的字符位置
+func markTerms(text []rune, terms []string) []bool {
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		t := []rune(strings.ToLower(term))
+		if len(t) == 0 {
+			continue
+		}
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) == string(t) {
+				for j := i; j < i+len(t); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+	return marked
+}
+
+// renderMarked 转义 HTML，并用 <mark> 包裹被标记的连续字符
+func renderMarked(text []rune, marked []bool) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		j := i
+		for j < len(text) && marked[j] == marked[i] {
+			j++
+		}
+		segment := html.EscapeString(string(text[i:j]))
+		if marked[i] {
+			b.WriteString("<mark>" + segment + "</mark>")
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// highlight 高亮整段文本中的检索词
+func highlight(text string, terms []string) string {
+	runes := []rune(text)
+	return renderMarked(runes, markTerms(runes, terms))
+}
+
+// snippet 截取第一个命中词附近的片段并高亮，没有命中时返回开头部分
+func snippet(text string, terms []string) string {
+	runes := []rune(text)
+	marked := markTerms(runes, terms)
+	first := 0
+	for i, m := range marked {
+		if m {
+			first = i
+			break
+		}
+	}
+	start := max(0, first-snippetRadius)
+	end := min(len(runes), first+snippetRadius*2)
+	result := renderMarked(runes[start:end], marked[start:end])
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(runes) {
+		result += "…"
+	}
+	return result
+}
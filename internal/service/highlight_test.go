@@ -0,0 +1,30 @@
+package service
+
+import (
+	"crist-blog/internal/tokenizer"
+	"testing"
+)
+
+// segmentTokenizer 返回固定切分结果的测试分词器
+type segmentTokenizer struct{ tokenizer.None }
+
+func (segmentTokenizer) Segment(text string) []string {
+	return []string{"中文", "正文", " <b>", "x"}
+}
+
+func TestHeadlineDocument(t *testing.T) {
+	if got := headlineDocument(tokenizer.None{}, "a"+markStart+"b"+wordBreak+"c"); got != "abc" {
+		t.Fatalf("markers were not stripped: %q", got)
+	}
+	want := "中文" + wordBreak + "正文" + wordBreak + " <b>" + wordBreak + "x"
+	if got := headlineDocument(segmentTokenizer{}, "中文正文 <b>x"); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderHeadline(t *testing.T) {
+	headline := "使用" + wordBreak + markStart + "数据库" + markStop + wordBreak + "<script>"
+	if got, want := renderHeadline(headline), "使用<mark>数据库</mark>&lt;script&gt;"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
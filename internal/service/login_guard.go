@@ -0,0 +1,150 @@
+package service
+
+import (
+	"crist-blog/internal/ratelimit"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginGuardConfig 登录防爆破的可配置项
+type LoginGuardConfig struct {
+	// Window 统计失败次数的滑动窗口
+	Window time.Duration
+	// FreeAttempts 同一用户名在窗口内可以连续失败的次数，超过后每次失败都需要等待
+	FreeAttempts int
+	// BaseDelay 超出免等待次数后的首次等待时间，此后每失败一次翻倍，最长 MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutThreshold 窗口内失败达到该次数时锁定账号 LockoutDuration，管理员可以提前解锁
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// IPMaxFailures 同一 IP 在窗口内允许的失败次数，防止对大量用户名撞库
+	IPMaxFailures int
+}
+
+// LoginThrottledError 表示登录尝试过于频繁，需要等待 RetryAfter 之后再试
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("登录尝试过于频繁，请 %d 秒后再试", int(e.RetryAfter.Seconds()+0.5))
+}
+
+// LoginGuard 按用户名和 IP 统计登录失败次数，实现递增等待和临时锁定
+type LoginGuard struct {
+	config       LoginGuardConfig
+	userFailures *ratelimit.SlidingWindow
+	ipFailures   *ratelimit.SlidingWindow
+
+	mu          sync.Mutex
+	lockedUntil map[string]time.Time
+}
+
+func NewLoginGuard(config LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{
+		config:       config,
+		userFailures: ratelimit.NewSlidingWindow(config.Window),
+		ipFailures:   ratelimit.NewSlidingWindow(config.Window),
+		lockedUntil:  make(map[string]time.Time),
+	}
+}
+
+func userKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// Reserve 原子地判断本次登录尝试是否允许，不允许时返回 *LoginThrottledError
+// 允许时先按失败为用户名和 IP 各预占一次，再由调用方校验密码或验证码，
+// 避免并发请求在同一时刻都通过检查而绕过递增等待和锁定。
+// 校验通过后调用 Release 归还预占，失败时调用 RecordFailure。username 为空时只限制 IP
+func (g *LoginGuard) Reserve(username, ip string) error {
+	key := userKey(username)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if key != "" {
+		if wait := g.userWait(key); wait > 0 {
+			return &LoginThrottledError{RetryAfter: wait}
+		}
+	}
+	if wait := g.ipFailures.Allow(ip, g.config.IPMaxFailures); wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	if key != "" {
+		g.userFailures.Add(key)
+	}
+	return nil
+}
+
+// userWait 返回该用户名还需等待的时间，调用方需持有 g.mu
+func (g *LoginGuard) userWait(key string) time.Duration {
+	var wait time.Duration
+	if until, locked := g.lockedUntil[key]; locked {
+		if time.Now().After(until) {
+			delete(g.lockedUntil, key)
+		} else {
+			wait = time.Until(until)
+		}
+	}
+
+	// 超出免等待次数后，距上次失败必须间隔 BaseDelay * 2^(n-FreeAttempts)
+	failures := g.userFailures.Count(key)
+	if failures >= g.config.FreeAttempts {
+		if retry := time.Until(g.userFailures.Last(key).Add(g.delay(failures))); retry > wait {
+			wait = retry
+		}
+	}
+	return wait
+}
+
+// delay 窗口内已失败 failures 次时下一次尝试前需要等待的时间
+func (g *LoginGuard) delay(failures int) time.Duration {
+	if failures < g.config.FreeAttempts {
+		return 0
+	}
+	delay := g.config.BaseDelay << uint(failures-g.config.FreeAttempts)
+	if delay > g.config.MaxDelay || delay <= 0 {
+		delay = g.config.MaxDelay
+	}
+	return delay
+}
+
+// RecordFailure 校验失败时保留 Reserve 的预占，达到阈值时锁定账号
+func (g *LoginGuard) RecordFailure(username string) {
+	key := userKey(username)
+	if key == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.userFailures.Count(key) >= g.config.LockoutThreshold {
+		g.lockedUntil[key] = time.Now().Add(g.config.LockoutDuration)
+	}
+}
+
+// Release 校验通过后归还 Reserve 预占的次数，不影响之前的失败记录
+func (g *LoginGuard) Release(username, ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ipFailures.Release(ip)
+	if key := userKey(username); key != "" {
+		g.userFailures.Release(key)
+	}
+}
+
+// RecordSuccess 登录完全成功（包括两步验证）后清除该用户名的失败记录
+func (g *LoginGuard) RecordSuccess(username string) {
+	g.userFailures.Reset(userKey(username))
+}
+
+// Unlock 解除账号锁定并清除失败记录
+func (g *LoginGuard) Unlock(username string) {
+	key := userKey(username)
+	g.userFailures.Reset(key)
+	g.mu.Lock()
+	delete(g.lockedUntil, key)
+	g.mu.Unlock()
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestLoginGuard(config LoginGuardConfig) *LoginGuard {
+	if config.Window == 0 {
+		config.Window = time.Hour
+	}
+	if config.IPMaxFailures == 0 {
+		config.IPMaxFailures = 1000
+	}
+	if config.LockoutThreshold == 0 {
+		config.LockoutThreshold = 1000
+	}
+	return NewLoginGuard(config)
+}
+
+func throttled(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("expected *LoginThrottledError, got %v", err)
+	}
+	return throttled.RetryAfter
+}
+
+func TestLoginGuardDelay(t *testing.T) {
+	g := newTestLoginGuard(LoginGuardConfig{
+		FreeAttempts: 2,
+		BaseDelay:    time.Second,
+		MaxDelay:     4 * time.Second,
+	})
+	cases := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, time.Second},
+		{3, 2 * time.Second},
+		{4, 4 * time.Second},
+		{5, 4 * time.Second},
+		{200, 4 * time.Second}, // 移位溢出时取 MaxDelay
+	}
+	for _, tc := range cases {
+		if got := g.delay(tc.failures); got != tc.want {
+			t.Errorf("delay(%d) = %s, want %s", tc.failures, got, tc.want)
+		}
+	}
+}
+
+func TestLoginGuardFreeAttemptsThenDelay(t *testing.T) {
+	g := newTestLoginGuard(LoginGuardConfig{
+		FreeAttempts: 2,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+	})
+	for i := 0; i < 2; i++ {
+		if err := g.Reserve("Alice", "1.2.3.4"); err != nil {
+			t.Fatalf("attempt %d: unexpected error %v", i+1, err)
+		}
+		g.RecordFailure("Alice")
+	}
+	// 用户名不区分大小写和首尾空格
+	wait := throttled(t, g.Reserve(" alice ", "5.6.7.8"))
+	if wait <= 59*time.Second || wait > time.Minute {
+		t.Fatalf("RetryAfter = %s, want about 1m", wait)
+	}
+	if err := g.Reserve("bob", "1.2.3.4"); err != nil {
+		t.Fatalf("other user should not be throttled: %v", err)
+	}
+}
+
+func TestLoginGuardLockout(t *testing.T) {
+	g := newTestLoginGuard(LoginGuardConfig{
+		FreeAttempts:     100,
+		LockoutThreshold: 3,
+		LockoutDuration:  10 * time.Minute,
+	})
+	for i := 0; i < 3; i++ {
+		if err := g.Reserve("alice", "1.2.3.4"); err != nil {
+			t.Fatalf("attempt %d: unexpected error %v", i+1, err)
+		}
+		g.RecordFailure("alice")
+	}
+	wait := throttled(t, g.Reserve("alice", "1.2.3.4"))
+	if wait <= 9*time.Minute || wait > 10*time.Minute {
+		t.Fatalf("RetryAfter = %s, want about 10m", wait)
+	}
+
+	g.Unlock("alice")
+	if err := g.Reserve("alice", "1.2.3.4"); err != nil {
+		t.Fatalf("unlocked user should be allowed: %v", err)
+	}
+}
+
+func TestLoginGuardIPLimitAndRelease(t *testing.T) {
+	g := newTestLoginGuard(LoginGuardConfig{IPMaxFailures: 2})
+	for i := 0; i < 2; i++ {
+		if err := g.Reserve("", "1.2.3.4"); err != nil {
+			t.Fatalf("attempt %d: unexpected error %v", i+1, err)
+		}
+	}
+	throttled(t, g.Reserve("", "1.2.3.4"))
+	if err := g.Reserve("", "5.6.7.8"); err != nil {
+		t.Fatalf("other IP should not be throttled: %v", err)
+	}
+
+	// 校验通过后归还预占，下一次尝试不再受限
+	g.Release("", "1.2.3.4")
+	if err := g.Reserve("", "1.2.3.4"); err != nil {
+		t.Fatalf("released reservation should allow another attempt: %v", err)
+	}
+}
+
+func TestLoginGuardSuccessKeepsEarlierFailuresUntilRecordSuccess(t *testing.T) {
+	g := newTestLoginGuard(LoginGuardConfig{
+		FreeAttempts: 1,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Minute,
+	})
+	if err := g.Reserve("alice", "1.2.3.4"); err != nil {
+		t.Fatal(err)
+	}
+	g.RecordFailure("alice")
+	throttled(t, g.Reserve("alice", "1.2.3.4"))
+
+	g.RecordSuccess("alice")
+	if err := g.Reserve("alice", "1.2.3.4"); err != nil {
+		t.Fatalf("RecordSuccess should clear failures: %v", err)
+	}
+}
+
+func TestLoginGuardReserveIsAtomic(t *testing.T) {
+	g := newTestLoginGuard(LoginGuardConfig{
+		FreeAttempts: 1,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Minute,
+	})
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if g.Reserve("alice", "1.2.3.4") == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 1 {
+		t.Fatalf("%d concurrent attempts were allowed, want 1", allowed)
+	}
+}
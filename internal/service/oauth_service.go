@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"crist-blog/internal/keyset"
+	"crist-blog/internal/model"
+	"crist-blog/internal/oauth"
+	"crist-blog/internal/repository"
+	"crypto/subtle"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+)
+
+// OAuthStateExpire 从跳转授权页到回调的最长时间
+const OAuthStateExpire = 10 * time.Minute
+
+var (
+	ErrInvalidOAuthState = errors.New("登录状态无效或已过期，请重试")
+	ErrOAuthNoAccount    = errors.New("该第三方账号未绑定任何用户，且未开放注册")
+	ErrIdentityNotFound  = errors.New("identity not found")
+)
+
+var usernameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+type OAuthService struct {
+	userRepo            *repository.UserRepository
+	identityRepo        *repository.UserIdentityRepository
+	providers           *oauth.Registry
+	keys                *keyset.KeySet
+	audit               *AuditService
+	registrationEnabled bool
+}
+
+func NewOAuthService(
+	userRepo *repository.UserRepository,
+	identityRepo *repository.UserIdentityRepository,
+	providers *oauth.Registry,
+	keys *keyset.KeySet,
+	audit *AuditService,
+	registrationEnabled bool) *OAuthService {
+	return &OAuthService{
+		userRepo:            userRepo,
+		identityRepo:        identityRepo,
+		providers:           providers,
+		keys:                keys,
+		audit:               audit,
+		registrationEnabled: registrationEnabled,
+	}
+}
+
+func (s *OAuthService) Providers() []string {
+	return s.providers.Names()
+}
+
+// Begin 生成授权页地址和签名的状态令牌
+// 状态令牌保存 state、PKCE verifier 和 nonce，由调用方写入 Cookie，回调时原样交给 Complete 校验
+func (s *OAuthService) Begin(providerName string) (authURL, stateToken string, err error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return "", "", err
+	}
+	state, err := randomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	now := time.Now()
+	stateToken, err = s.keys.Sign(jwt.MapClaims{
+		"typ":      "oauth_state",
+		"provider": providerName,
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+		"iat":      now.Unix(),
+		"exp":      now.Add(OAuthStateExpire).Unix(),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return provider.AuthCodeURL(state, verifier, nonce), stateToken, nil
+}
+
+// Complete 校验回调参数，换取第三方身份并返回对应的本地用户
+// 查找顺序：已绑定的第三方账号 → 已验证邮箱相同的用户（自动绑定）→ 开放注册时新建用户
+func (s *OAuthService) Complete(ctx context.Context, actor Actor, providerName, code, state, stateToken string) (*model.User, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.parseState(stateToken)
+	if err != nil || claims["provider"] != providerName {
+		return nil, ErrInvalidOAuthState
+	}
+	expectedState, _ := claims["state"].(string)
+	if expectedState == "" || subtle.ConstantTimeCompare([]byte(expectedState), []byte(state)) != 1 {
+		return nil, ErrInvalidOAuthState
+	}
+	verifier, _ := claims["verifier"].(string)
+	nonce, _ := claims["nonce"].(string)
+
+	identity, err := provider.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if linked, err := s.identityRepo.FindByProviderSubject(providerName, identity.Subject); err == nil {
+		return s.userRepo.GetByID(linked.UserID)
+	}
+
+	// 只有第三方确认过的邮箱才能用于绑定已有账号，否则任何人都可以冒用他人邮箱登录
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errors.New("第三方账号没有已验证的邮箱")
+	}
+	link := &model.UserIdentity{
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	if user, err := s.userRepo.GetByEmail(identity.Email); err == nil {
+		// 本地邮箱也必须已验证，防止有人先用他人邮箱注册再等对方用第三方登录时被绑定
+		if user.EmailVerifiedAt == nil {
+			return nil, ErrOAuthNoAccount
+		}
+		link.UserID = user.ID
+		err := s.identityRepo.Create(link)
+		s.recordLink(actor, user, link, err)
+		if err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	if !s.registrationEnabled {
+		return nil, ErrOAuthNoAccount
+	}
+	user, err := s.newUser(identity)
+	if err != nil {
+		return nil, err
+	}
+	err = s.identityRepo.CreateUserWithIdentity(user, link)
+	s.recordLink(actor, user, link, err)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// recordLink 记录第三方账号绑定，回调请求尚未登录，以被绑定的用户作为审计主体
+func (s *OAuthService) recordLink(actor Actor, user *model.User, link *model.UserIdentity, err error) {
+	actor.UserID, actor.Username = user.ID, user.Username
+	var targetID string
+	if err == nil {
+		targetID = link.ID.String()
+	}
+	s.audit.Record(actor, model.AuditIdentityLink, "identity", targetID, err)
+}
+
+func (s *OAuthService) parseState(stateToken string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(stateToken, s.keys.Keyfunc, jwt.WithValidMethods(s.keys.ValidMethods()))
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidOAuthState
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != "oauth_state" {
+		return nil, ErrInvalidOAuthState
+	}
+	return claims, nil
+}
+
+// newUser 为第三方账号创建本地用户，用户名取邮箱前缀，冲突时追加随机后缀
+// 密码设为随机值，用户之后可以通过找回密码设置
+func (s *OAuthService) newUser(identity *oauth.Identity) (*model.User, error) {
+	base := usernameInvalidChars.ReplaceAllString(strings.Split(identity.Email, "@")[0], "")
+	if len(base) > 24 {
+		base = base[:24]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+	username := base
+	for i := 0; ; i++ {
+		exists, err := s.userRepo.ExistsByUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			break
+		}
+		if i >= 5 {
+			return nil, errors.New("无法生成可用的用户名")
+		}
+		suffix, err := randomToken(2)
+		if err != nil {
+			return nil, err
+		}
+		username = base + "-" + suffix
+	}
+
+	password, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	nickname := identity.Name
+	if nickname == "" {
+		nickname = username
+	}
+	return &model.User{
+		ID:              uuid.New(),
+		Username:        username,
+		PasswordHash:    string(passwordHash),
+		Nickname:        nickname,
+		Email:           identity.Email,
+		Avatar:          identity.AvatarURL,
+		Role:            model.RoleReader,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}, nil
+}
+
+func (s *OAuthService) ListIdentities(userID uuid.UUID) ([]*model.UserIdentity, error) {
+	return s.identityRepo.ListByUserID(userID)
+}
+
+func (s *OAuthService) Unlink(actor Actor, identityID uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditIdentityUnlink, "identity", identityID.String(), err)
+	}()
+	deleted, err := s.identityRepo.Delete(actor.UserID, identityID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"crist-blog/internal/model"
+	"crist-blog/internal/oauth"
+	"crist-blog/internal/repository"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+const mockClientID = "crist-blog"
+
+// mockAuthorization 是模拟授权页同意后记录的一次授权
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+// mockOIDCIssuer 是基于 httptest 的最小 OpenID Connect 提供方：
+// 支持发现文档、JWKS 和授权码换取 id_token，换取时按 S256 校验 PKCE verifier
+type mockOIDCIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+func newMockOIDCIssuer(t *testing.T) *mockOIDCIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDCIssuer{key: key, codes: make(map[string]mockAuthorization)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/jwks", m.jwks)
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockOIDCIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                m.server.URL,
+		"authorization_endpoint":                m.server.URL + "/authorize",
+		"token_endpoint":                        m.server.URL + "/token",
+		"jwks_uri":                              m.server.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (m *mockOIDCIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := m.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "mock",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (m *mockOIDCIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	m.mu.Lock()
+	auth, ok := m.codes[r.PostForm.Get("code")]
+	m.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   mockClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": auth.nonce,
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "mock"
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// authorize 模拟用户在授权页同意，根据授权地址中的 PKCE challenge 和 nonce 签发授权码
+// claims 为 id_token 中的用户信息，可以覆盖 nonce 以模拟被篡改的响应
+func (m *mockOIDCIssuer) authorize(t *testing.T, authURL string, claims jwt.MapClaims) (code, state string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL without S256 PKCE challenge: %s", authURL)
+	}
+	if q.Get("client_id") != mockClientID {
+		t.Fatalf("client_id = %q", q.Get("client_id"))
+	}
+	code, err = randomToken(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	m.codes[code] = mockAuthorization{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), claims: claims}
+	m.mu.Unlock()
+	return code, q.Get("state")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newTestOAuthService 创建只配置了 issuer 这一个提供方（名为 mock）的 OAuthService
+func newTestOAuthService(t *testing.T, db *gorm.DB, issuer *mockOIDCIssuer, registrationEnabled bool) *OAuthService {
+	t.Helper()
+	providers := oauth.NewRegistry(context.Background(), []oauth.Config{{
+		Name:         "mock",
+		Type:         "oidc",
+		ClientID:     mockClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/api/oauth/mock/callback",
+		Issuer:       issuer.server.URL,
+	}})
+	if names := providers.Names(); len(names) != 1 || names[0] != "mock" {
+		t.Fatalf("mock provider was not registered: %v", names)
+	}
+	return NewOAuthService(
+		repository.NewUserRepository(db),
+		repository.NewUserIdentityRepository(db),
+		providers,
+		newTestKeySet(t),
+		newTestAuditService(db),
+		registrationEnabled,
+	)
+}
+
+// oauthLogin 走完一次完整的授权码流程
+func oauthLogin(t *testing.T, svc *OAuthService, issuer *mockOIDCIssuer, claims jwt.MapClaims) (*model.User, error) {
+	t.Helper()
+	authURL, stateToken, err := svc.Begin("mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := issuer.authorize(t, authURL, claims)
+	return svc.Complete(context.Background(), Actor{}, "mock", code, state, stateToken)
+}
+
+func identityCount(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&model.UserIdentity{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestOAuthPKCERoundTripCreatesUser(t *testing.T) {
+	db := newTestDB(t)
+	issuer := newMockOIDCIssuer(t)
+	svc := newTestOAuthService(t, db, issuer, true)
+	user, err := oauthLogin(t, svc, issuer, jwt.MapClaims{"sub": "u-1", "email": "new@example.com", "email_verified": true, "name": "New"})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if user.Email != "new@example.com" || user.Nickname != "New" || user.EmailVerifiedAt == nil {
+		t.Fatalf("unexpected user %+v", user)
+	}
+
+	// 再次登录通过已绑定的 subject 找到同一个用户
+	again, err := oauthLogin(t, svc, issuer, jwt.MapClaims{"sub": "u-1", "email": "changed@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again.ID != user.ID {
+		t.Fatalf("second login returned user %s, want %s", again.ID, user.ID)
+	}
+	// 只有首次绑定写审计日志
+	if actions := auditActions(t, db); !slices.Equal(actions, []string{model.AuditIdentityLink}) {
+		t.Fatalf("audit actions %v, want a single identity link", actions)
+	}
+}
+
+func TestOAuthPKCEVerifierMustMatch(t *testing.T) {
+	db := newTestDB(t)
+	issuer := newMockOIDCIssuer(t)
+	svc := newTestOAuthService(t, db, issuer, true)
+	_, stateToken, err := svc.Begin("mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 授权码绑定的是另一次登录的 challenge，当前状态令牌中的 verifier 无法通过校验
+	otherURL, _, err := svc.Begin("mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := issuer.authorize(t, otherURL, jwt.MapClaims{"sub": "u-1", "email": "a@example.com", "email_verified": true})
+	claims, err := svc.parseState(stateToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _ := claims["state"].(string)
+	_, err = svc.Complete(context.Background(), Actor{}, "mock", code, state, stateToken)
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) || retrieveErr.ErrorDescription != "PKCE verification failed" {
+		t.Fatalf("got %v, want PKCE verification failure", err)
+	}
+}
+
+func TestOAuthStateMismatch(t *testing.T) {
+	db := newTestDB(t)
+	issuer := newMockOIDCIssuer(t)
+	svc := newTestOAuthService(t, db, issuer, true)
+	authURL, stateToken, err := svc.Begin("mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := issuer.authorize(t, authURL, jwt.MapClaims{"sub": "u-1", "email": "a@example.com", "email_verified": true})
+	if _, err := svc.Complete(context.Background(), Actor{}, "mock", code, "forged-state", stateToken); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Fatalf("got %v, want ErrInvalidOAuthState", err)
+	}
+	if _, err := svc.Complete(context.Background(), Actor{}, "mock", code, "", "not-a-token"); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Fatalf("invalid state token: got %v, want ErrInvalidOAuthState", err)
+	}
+}
+
+func TestOAuthNonceMismatch(t *testing.T) {
+	db := newTestDB(t)
+	issuer := newMockOIDCIssuer(t)
+	svc := newTestOAuthService(t, db, issuer, true)
+	_, err := oauthLogin(t, svc, issuer, jwt.MapClaims{"sub": "u-1", "email": "a@example.com", "email_verified": true, "nonce": "replayed"})
+	if err == nil || !strings.Contains(err.Error(), "nonce mismatch") {
+		t.Fatalf("got %v, want nonce mismatch", err)
+	}
+	if identityCount(t, db) != 0 {
+		t.Fatal("no identity should be linked")
+	}
+}
+
+func TestOAuthRefusesUnverifiedEmail(t *testing.T) {
+	db := newTestDB(t)
+	issuer := newMockOIDCIssuer(t)
+	svc := newTestOAuthService(t, db, issuer, true)
+	createTestUser(t, db, "alice")
+	_, err := oauthLogin(t, svc, issuer, jwt.MapClaims{"sub": "u-1", "email": "alice@example.com", "email_verified": false})
+	if err == nil || !strings.Contains(err.Error(), "没有已验证的邮箱") {
+		t.Fatalf("got %v, want unverified email refusal", err)
+	}
+	if identityCount(t, db) != 0 {
+		t.Fatal("no identity should be linked")
+	}
+}
+
+func TestOAuthLinksExistingVerifiedUser(t *testing.T) {
+	db := newTestDB(t)
+	issuer := newMockOIDCIssuer(t)
+	svc := newTestOAuthService(t, db, issuer, false)
+	alice := createTestUser(t, db, "alice")
+	user, err := oauthLogin(t, svc, issuer, jwt.MapClaims{"sub": "u-1", "email": "alice@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if user.ID != alice.ID {
+		t.Fatalf("got user %s, want %s", user.ID, alice.ID)
+	}
+	identities, err := svc.ListIdentities(alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 1 || identities[0].Provider != "mock" || identities[0].Subject != "u-1" {
+		t.Fatalf("unexpected identities %+v", identities)
+	}
+}
+
+func TestOAuthDoesNotLinkUnverifiedLocalUser(t *testing.T) {
+	db := newTestDB(t)
+	issuer := newMockOIDCIssuer(t)
+	svc := newTestOAuthService(t, db, issuer, true)
+	bob := createTestUser(t, db, "bob")
+	if err := db.Model(bob).Update("email_verified_at", nil).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oauthLogin(t, svc, issuer, jwt.MapClaims{"sub": "u-2", "email": "bob@example.com", "email_verified": true}); !errors.Is(err, ErrOAuthNoAccount) {
+		t.Fatalf("got %v, want ErrOAuthNoAccount", err)
+	}
+	if identityCount(t, db) != 0 {
+		t.Fatal("no identity should be linked")
+	}
+}
+
+func TestOAuthWithoutRegistration(t *testing.T) {
+	db := newTestDB(t)
+	issuer := newMockOIDCIssuer(t)
+	svc := newTestOAuthService(t, db, issuer, false)
+	if _, err := oauthLogin(t, svc, issuer, jwt.MapClaims{"sub": "u-3", "email": "nobody@example.com", "email_verified": true}); !errors.Is(err, ErrOAuthNoAccount) {
+		t.Fatalf("got %v, want ErrOAuthNoAccount", err)
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy 密码强度规则
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// Validate 校验密码是否满足规则，不满足时返回说明全部要求的错误
+func (p PasswordPolicy) Validate(password string) error {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	var missing []string
+	if len([]rune(password)) < p.MinLength {
+		missing = append(missing, fmt.Sprintf("长度不少于 %d 位", p.MinLength))
+	}
+	if p.RequireUpper && !hasUpper {
+		missing = append(missing, "包含大写字母")
+	}
+	if p.RequireLower && !hasLower {
+		missing = append(missing, "包含小写字母")
+	}
+	if p.RequireDigit && !hasDigit {
+		missing = append(missing, "包含数字")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		missing = append(missing, "包含特殊字符")
+	}
+	if len(missing) > 0 {
+		return &PasswordPolicyError{Missing: missing}
+	}
+	return nil
+}
+
+// PasswordPolicyError 密码不满足强度规则，Missing 为未满足的要求
+type PasswordPolicyError struct {
+	Missing []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "密码需要" + strings.Join(e.Missing, "、")
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"crist-blog/internal/mailer"
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrWrongPassword = errors.New("原密码错误")
+
+// PasswordConfig 密码找回相关的可配置项
+type PasswordConfig struct {
+	// BaseURL 前端地址，用于拼接重置链接
+	BaseURL string
+	// ResetTTL 重置链接有效期
+	ResetTTL time.Duration
+}
+
+type PasswordService struct {
+	userRepo         *repository.UserRepository
+	userTokenRepo    *repository.UserTokenRepository
+	refreshTokenRepo *repository.RefreshTokenRepository
+	mailer           mailer.Mailer
+	policy           PasswordPolicy
+	audit            *AuditService
+	config           PasswordConfig
+}
+
+func NewPasswordService(
+	userRepo *repository.UserRepository,
+	userTokenRepo *repository.UserTokenRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	mailer mailer.Mailer,
+	policy PasswordPolicy,
+	audit *AuditService,
+	config PasswordConfig) *PasswordService {
+	return &PasswordService{
+		userRepo:         userRepo,
+		userTokenRepo:    userTokenRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		mailer:           mailer,
+		policy:           policy,
+		audit:            audit,
+		config:           config,
+	}
+}
+
+// ForgotPassword 向邮箱发送一次性的密码重置链接
+// 为避免泄露邮箱是否注册，邮箱不存在或邮件发送失败时同样视为成功，只记录日志和审计
+func (s *PasswordService) ForgotPassword(actor Actor, email string) {
+	email = strings.TrimSpace(email)
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		s.audit.Record(actor, model.AuditPasswordForgot, "email", email, ErrUserNotFound)
+		return
+	}
+	err = s.sendResetLink(user, "重置你的密码",
+		"%s，你好：\n\n我们收到了重置密码的请求，请在 %s 内点击以下链接设置新密码：\n\n%s\n\n如果这不是你本人的操作，请忽略本邮件，你的密码不会改变。\n")
+	if err != nil {
+		log.Printf("warning: failed to send password reset email to user %s: %v", user.ID, err)
+	}
+	s.audit.Record(actor, model.AuditPasswordForgot, "user", user.ID.String(), err)
+}
+
+// ForceReset 管理员强制用户重置密码：撤销全部会话，重置前禁止使用旧密码登录，并发送重置链接
+func (s *PasswordService) ForceReset(user *model.User) error {
+	if err := s.userRepo.SetPasswordResetRequired(user.ID, true); err != nil {
+		return err
+	}
+	if err := s.refreshTokenRepo.RevokeAllByUserID(user.ID); err != nil {
+		return err
+	}
+	return s.sendResetLink(user, "请重新设置你的密码",
+		"%s，你好：\n\n管理员要求你重新设置密码，在此之前无法使用原密码登录。请在 %s 内点击以下链接设置新密码：\n\n%s\n")
+}
+
+// sendResetLink 作废旧的重置链接并发送新的重置邮件
+// body 为邮件正文模板，依次填入昵称、链接有效期和链接
+func (s *PasswordService) sendResetLink(user *model.User, subject, body string) error {
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	// 同一时间只保留最新的重置链接
+	if err := s.userTokenRepo.InvalidateByUserID(user.ID, model.TokenPurposeResetPassword); err != nil {
+		return err
+	}
+	err = s.userTokenRepo.Create(&model.UserToken{
+		UserID:    user.ID,
+		Purpose:   model.TokenPurposeResetPassword,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.config.ResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(s.config.BaseURL, "/"), url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf(body, user.Nickname, s.config.ResetTTL, link),
+	})
+}
+
+// ResetPassword 使用重置令牌设置新密码
+func (s *PasswordService) ResetPassword(actor Actor, token, newPassword string) (err error) {
+	if err := s.policy.Validate(newPassword); err != nil {
+		return err
+	}
+	ut, err := s.userTokenRepo.Consume(model.TokenPurposeResetPassword, hashToken(token))
+	if err != nil {
+		s.audit.Record(actor, model.AuditPasswordReset, "user", "", ErrInvalidToken)
+		return ErrInvalidToken
+	}
+	defer func() {
+		s.audit.Record(actor, model.AuditPasswordReset, "user", ut.UserID.String(), err)
+	}()
+	return s.setPassword(ut.UserID, newPassword)
+}
+
+// ChangePassword 已登录用户修改密码，需要验证原密码
+func (s *PasswordService) ChangePassword(actor Actor, oldPassword, newPassword string) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditPasswordChange, "user", actor.UserID.String(), err)
+	}()
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(oldPassword)); err != nil {
+		return ErrWrongPassword
+	}
+	if err := s.policy.Validate(newPassword); err != nil {
+		return err
+	}
+	return s.setPassword(user.ID, newPassword)
+}
+
+// setPassword 更新密码并撤销该用户的全部刷新令牌，所有设备都需要重新登录
+func (s *PasswordService) setPassword(userID uuid.UUID, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(userID, string(hash)); err != nil {
+		return err
+	}
+	if err := s.refreshTokenRepo.RevokeAllByUserID(userID); err != nil {
+		log.Printf("warning: failed to revoke refresh tokens of user %s: %v", userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PersonalAccessTokenPrefix 个人访问令牌的固定前缀，AuthMiddleware 据此区分 JWT
+const PersonalAccessTokenPrefix = "cbpat_"
+
+var (
+	ErrInvalidScope  = errors.New("令牌权限范围无效或超出当前角色的权限")
+	ErrTokenNotFound = errors.New("token not found")
+)
+
+type PersonalAccessTokenService struct {
+	patRepo     *repository.PersonalAccessTokenRepository
+	userRepo    *repository.UserRepository
+	rbacService *RBACService
+	audit       *AuditService
+}
+
+func NewPersonalAccessTokenService(
+	patRepo *repository.PersonalAccessTokenRepository,
+	userRepo *repository.UserRepository,
+	rbacService *RBACService,
+	audit *AuditService) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{
+		patRepo:     patRepo,
+		userRepo:    userRepo,
+		rbacService: rbacService,
+		audit:       audit,
+	}
+}
+
+// Create 创建个人访问令牌，返回只展示一次的令牌明文
+// 令牌的权限范围不能超出用户当前角色拥有的权限
+func (s *PersonalAccessTokenService) Create(actor Actor, req *model.CreatePersonalAccessTokenRequest) (token string, pat *model.PersonalAccessToken, err error) {
+	defer func() {
+		var targetID string
+		if pat != nil {
+			targetID = pat.ID.String()
+		}
+		s.audit.Record(actor, model.AuditTokenCreate, "token", targetID, err)
+	}()
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return "", nil, errors.New("令牌名称不能为空")
+	}
+	if len(req.Scopes) == 0 {
+		return "", nil, ErrInvalidScope
+	}
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(model.AllPermissions, scope) {
+			return "", nil, ErrInvalidScope
+		}
+		allowed, err := s.rbacService.RoleHasPermission(user.Role, scope)
+		if err != nil {
+			return "", nil, err
+		}
+		if !allowed {
+			return "", nil, ErrInvalidScope
+		}
+	}
+
+	raw, err := randomToken(20)
+	if err != nil {
+		return "", nil, err
+	}
+	token = PersonalAccessTokenPrefix + raw
+	pat = &model.PersonalAccessToken{
+		UserID:      actor.UserID,
+		Name:        name,
+		TokenPrefix: token[:len(PersonalAccessTokenPrefix)+4],
+		TokenHash:   hashToken(token),
+		Scopes:      req.Scopes,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresIn)
+		pat.ExpiresAt = &expiresAt
+	}
+	if err := s.patRepo.Create(pat); err != nil {
+		return "", nil, err
+	}
+	return token, pat, nil
+}
+
+func (s *PersonalAccessTokenService) List(userID uuid.UUID) ([]*model.PersonalAccessToken, error) {
+	return s.patRepo.ListByUserID(userID)
+}
+
+func (s *PersonalAccessTokenService) Revoke(actor Actor, id uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditTokenRevoke, "token", id.String(), err)
+	}()
+	revoked, err := s.patRepo.Revoke(actor.UserID, id)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func newTestPATService(db *gorm.DB) *PersonalAccessTokenService {
+	userRepo := repository.NewUserRepository(db)
+	rbac := NewRBACService(userRepo, repository.NewRoleRepository(db))
+	return NewPersonalAccessTokenService(repository.NewPersonalAccessTokenRepository(db), userRepo, rbac, newTestAuditService(db))
+}
+
+// createTestAuthor 创建一个 author 角色的用户，author 可以创建和发布文章，但不能管理分类
+func createTestAuthor(t *testing.T, db *gorm.DB) *model.User {
+	t.Helper()
+	grantPermissions(t, db, model.RoleAuthor, model.PermPostCreate, model.PermPostEditOwn, model.PermPostPublish)
+	user := createTestUser(t, db, "alice")
+	if err := db.Model(user).Update("role", model.RoleAuthor).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestCreatePersonalAccessTokenScopes(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPATService(db)
+	user := createTestAuthor(t, db)
+	actor := Actor{UserID: user.ID, Username: user.Username}
+	cases := []struct {
+		name    string
+		scopes  []string
+		wantErr bool
+	}{
+		{"scopes within role", []string{model.PermPostCreate, model.PermPostPublish}, false},
+		{"no scopes", nil, true},
+		{"unknown scope", []string{"post:everything"}, true},
+		{"scope beyond role", []string{model.PermPostCreate, model.PermCategoryManage}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, pat, err := svc.Create(actor, &model.CreatePersonalAccessTokenRequest{
+				Name:   "ci",
+				Scopes: tc.scopes,
+			})
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidScope) {
+					t.Fatalf("got %v, want ErrInvalidScope", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if !strings.HasPrefix(token, PersonalAccessTokenPrefix) || !strings.HasPrefix(token, pat.TokenPrefix) {
+				t.Fatalf("unexpected token %q with prefix %q", token, pat.TokenPrefix)
+			}
+		})
+	}
+}
+
+func TestAuthenticatePersonalAccessToken(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPATService(db)
+	user := createTestAuthor(t, db)
+	actor := Actor{UserID: user.ID, Username: user.Username}
+	auth := newTestAuthService(t, db, AuthConfig{})
+	token, pat, err := svc.Create(actor, &model.CreatePersonalAccessTokenRequest{
+		Name:   "ci",
+		Scopes: []string{model.PermPostCreate},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := auth.AuthenticatePersonalAccessToken(token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got.UserID != user.ID || !slices.Equal([]string(got.Scopes), []string{model.PermPostCreate}) {
+		t.Fatalf("got user %s scopes %v", got.UserID, got.Scopes)
+	}
+
+	if _, err := auth.AuthenticatePersonalAccessToken(token + "x"); !errors.Is(err, ErrInvalidPersonalAccessToken) {
+		t.Fatalf("wrong token: got %v, want ErrInvalidPersonalAccessToken", err)
+	}
+
+	if err := svc.Revoke(actor, pat.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.AuthenticatePersonalAccessToken(token); !errors.Is(err, ErrInvalidPersonalAccessToken) {
+		t.Fatalf("revoked token: got %v, want ErrInvalidPersonalAccessToken", err)
+	}
+	actions := auditActions(t, db)
+	if !slices.Contains(actions, model.AuditTokenCreate) || !slices.Contains(actions, model.AuditTokenRevoke) {
+		t.Fatalf("audit actions %v, want token create and revoke", actions)
+	}
+}
+
+func TestAuthenticateExpiredPersonalAccessToken(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPATService(db)
+	user := createTestAuthor(t, db)
+	actor := Actor{UserID: user.ID, Username: user.Username}
+	auth := newTestAuthService(t, db, AuthConfig{})
+	token, pat, err := svc.Create(actor, &model.CreatePersonalAccessTokenRequest{
+		Name:      "ci",
+		Scopes:    []string{model.PermPostCreate},
+		ExpiresIn: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(pat).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.AuthenticatePersonalAccessToken(token); !errors.Is(err, ErrInvalidPersonalAccessToken) {
+		t.Fatalf("expired token: got %v, want ErrInvalidPersonalAccessToken", err)
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxBulkPosts 一次批量操作最多涉及的文章数
+const MaxBulkPosts = 500
+
+var (
+	ErrInvalidBulkRequest = errors.New("批量操作参数无效")
+	ErrBulkNoPosts        = errors.New("没有选中任何文章")
+	ErrBulkTooManyPosts   = errors.New("一次最多操作 " + strconv.Itoa(MaxBulkPosts) + " 篇文章")
+)
+
+// errBulkSkipped 原子模式下其他文章失败导致本篇未执行
+var errBulkSkipped = errors.New("其他文章操作失败，本篇未执行")
+
+// bulkStatuses 批量修改状态时允许的目标状态
+var bulkStatuses = []model.PostStatus{model.Draft, model.Published, model.Private, model.Scheduled, model.Protected}
+
+// BulkAuthorizer 检查当前用户能否对某篇文章执行批量操作，不允许时返回错误
+type BulkAuthorizer func(post *model.Post) error
+
+// validateBulk 检查操作类型及其参数
+func validateBulk(req *model.BulkPostRequest) error {
+	switch req.Action {
+	case model.BulkSetStatus:
+		if !slices.Contains(bulkStatuses, model.PostStatus(req.Status)) {
+			return ErrInvalidBulkRequest
+		}
+	case model.BulkSetCategory:
+		if _, err := uuid.Parse(req.CategoryID); err != nil {
+			return ErrInvalidBulkRequest
+		}
+	case model.BulkAddTags, model.BulkRemoveTags:
+		if len(normalizeTags(req.Tags)) == 0 {
+			return ErrInvalidBulkRequest
+		}
+	case model.BulkDelete, model.BulkRestore:
+	default:
+		return ErrInvalidBulkRequest
+	}
+	return nil
+}
+
+// normalizeTags 去掉标签两端的空白、空标签和重复标签
+func normalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// uniqueIDs 去掉重复的文章ID，保持原有顺序
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// ResolveBulkIDs 按条件选出批量操作的文章，restore 操作在回收站中查找
+func (s *PostService) ResolveBulkIDs(action string, q *model.PostQuery) ([]uint, error) {
+	ids, err := s.PostRepo.ListIDs(q, action == model.BulkRestore, MaxBulkPosts+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > MaxBulkPosts {
+		return nil, ErrBulkTooManyPosts
+	}
+	return ids, nil
+}
+
+// applyBulkChange 在内存中修改文章，不保存
+func applyBulkChange(post *model.Post, req *model.BulkPostRequest, now time.Time) error {
+	switch req.Action {
+	case model.BulkSetStatus:
+		status := model.PostStatus(req.Status)
+		if status == model.Published && post.Status == model.Scheduled {
+			// 定时文章改为立即发布
+			post.PublishedAt = nil
+		}
+		post.Status = status
+		if err := applySchedule(post, now); err != nil {
+			return err
+		}
+		return applyProtection(post)
+	case model.BulkSetCategory:
+		post.CategoryID = uuid.MustParse(req.CategoryID)
+	case model.BulkAddTags:
+		for _, tag := range normalizeTags(req.Tags) {
+			if !slices.Contains(post.Tags, tag) {
+				post.Tags = append(post.Tags, tag)
+			}
+		}
+	case model.BulkRemoveTags:
+		remove := normalizeTags(req.Tags)
+		post.Tags = slices.DeleteFunc(post.Tags, func(tag string) bool {
+			return slices.Contains(remove, tag)
+		})
+	}
+	return nil
+}
+
+// BulkApply 对多篇文章执行同一操作，可以执行的文章在一个事务中修改，结果逐篇返回
+// 文章不存在、没有权限或不满足条件时只有该篇失败；Atomic 为 true 时任意一篇失败则全部不执行
+func (s *PostService) BulkApply(actor Actor, req *model.BulkPostRequest, ids []uint, authorize BulkAuthorizer) (*model.BulkPostResult, error) {
+	if err := validateBulk(req); err != nil {
+		return nil, err
+	}
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil, ErrBulkNoPosts
+	}
+	if len(ids) > MaxBulkPosts {
+		return nil, ErrBulkTooManyPosts
+	}
+	posts, err := s.PostRepo.GetByIDs(ids, req.Action == model.BulkRestore)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*model.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	result := &model.BulkPostResult{Action: req.Action, Items: make([]*model.BulkItemResult, 0, len(ids))}
+	var pending []*model.Post
+	var pendingItems []*model.BulkItemResult
+	now := time.Now()
+	for _, id := range ids {
+		item := &model.BulkItemResult{ID: id}
+		result.Items = append(result.Items, item)
+		post, ok := byID[id]
+		if !ok {
+			item.Error = "Post not found"
+			continue
+		}
+		if err := authorize(post); err != nil {
+			item.Error = err.Error()
+			continue
+		}
+		if err := applyBulkChange(post, req, now); err != nil {
+			item.Error = err.Error()
+			continue
+		}
+		pending = append(pending, post)
+		pendingItems = append(pendingItems, item)
+	}
+
+	if len(pending) > 0 && (!req.Atomic || len(pending) == len(ids)) {
+		if err := s.saveBulk(req.Action, pending); err != nil {
+			if !errors.Is(err, repository.ErrStaleVersion) {
+				return nil, err
+			}
+			for _, item := range pendingItems {
+				item.Error = ErrPostVersionConflict.Error()
+			}
+		} else {
+			for _, item := range pendingItems {
+				item.OK = true
+			}
+			s.afterBulk(actor, req.Action, pending)
+		}
+	} else {
+		for _, item := range pendingItems {
+			item.Error = errBulkSkipped.Error()
+		}
+	}
+
+	for _, item := range result.Items {
+		if item.OK {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// saveBulk 在一个事务中保存批量操作的结果
+func (s *PostService) saveBulk(action string, posts []*model.Post) error {
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	switch action {
+	case model.BulkDelete:
+		return s.PostRepo.DeleteAll(ids)
+	case model.BulkRestore:
+		return s.PostRepo.RestoreAll(ids)
+	default:
+		return s.PostRepo.UpdateAll(posts)
+	}
+}
+
+// afterBulk 批量操作成功后逐篇记录审计日志，修改过的文章更新检索索引，标签变化时记录版本
+// 版本快照只包含标题、正文、标签等内容字段，不含状态和分类，
+// set_status/set_category 不会产生与上一版本不同的快照，因此不记录版本，这两类变更由审计日志追溯
+func (s *PostService) afterBulk(actor Actor, action string, posts []*model.Post) {
+	auditAction := model.AuditPostUpdate
+	switch action {
+	case model.BulkDelete:
+		auditAction = model.AuditPostDelete
+	case model.BulkRestore:
+		auditAction = model.AuditPostRestore
+	}
+	for _, post := range posts {
+		s.audit.Record(actor, auditAction, "post", strconv.FormatUint(uint64(post.ID), 10), nil)
+		switch action {
+		case model.BulkSetStatus:
+			s.reindex(post)
+		case model.BulkAddTags, model.BulkRemoveTags:
+			s.reindex(post)
+			s.recordRevision(actor, post)
+		}
+	}
+}
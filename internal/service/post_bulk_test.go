@@ -0,0 +1,232 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"errors"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// allowAll 允许对任意文章执行批量操作
+func allowAll(*model.Post) error { return nil }
+
+// bulkItemErrors 按文章顺序返回每篇的错误，成功的为空字符串
+func bulkItemErrors(result *model.BulkPostResult) []string {
+	errs := make([]string, 0, len(result.Items))
+	for _, item := range result.Items {
+		errs = append(errs, item.Error)
+	}
+	return errs
+}
+
+func TestBulkApplyValidatesRequest(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	tests := []struct {
+		req     model.BulkPostRequest
+		ids     []uint
+		wantErr error
+	}{
+		{model.BulkPostRequest{Action: "publish"}, []uint{1}, ErrInvalidBulkRequest},
+		{model.BulkPostRequest{Action: model.BulkSetStatus, Status: "archived"}, []uint{1}, ErrInvalidBulkRequest},
+		{model.BulkPostRequest{Action: model.BulkSetCategory, CategoryID: "x"}, []uint{1}, ErrInvalidBulkRequest},
+		{model.BulkPostRequest{Action: model.BulkAddTags, Tags: []string{" ", ""}}, []uint{1}, ErrInvalidBulkRequest},
+		{model.BulkPostRequest{Action: model.BulkDelete}, nil, ErrBulkNoPosts},
+	}
+	for _, tt := range tests {
+		if _, err := svc.BulkApply(Actor{}, &tt.req, tt.ids, allowAll); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s (%d ids): err = %v, want %v", tt.req.Action, len(tt.ids), err, tt.wantErr)
+		}
+	}
+
+	ids := make([]uint, MaxBulkPosts+1)
+	for i := range ids {
+		ids[i] = uint(i + 1)
+	}
+	if _, err := svc.BulkApply(Actor{}, &model.BulkPostRequest{Action: model.BulkDelete}, ids, allowAll); !errors.Is(err, ErrBulkTooManyPosts) {
+		t.Errorf("err = %v, want ErrBulkTooManyPosts", err)
+	}
+}
+
+func TestBulkApplyPartialResults(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	own := createTestPost(t, svc, author, "Own")
+	others := createTestPost(t, svc, author, "Others")
+	forbidden := errors.New("forbidden")
+	authorize := func(post *model.Post) error {
+		if post.ID == others.ID {
+			return forbidden
+		}
+		return nil
+	}
+
+	req := &model.BulkPostRequest{Action: model.BulkAddTags, Tags: []string{"go", " go ", "web"}}
+	result, err := svc.BulkApply(Actor{UserID: author.ID}, req, []uint{own.ID, others.ID, 999, own.ID}, authorize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"", "forbidden", "Post not found"}
+	if got := bulkItemErrors(result); !slices.Equal(got, want) || !result.Items[0].OK {
+		t.Fatalf("items = %q, want %q", got, want)
+	}
+	if result.Succeeded != 1 || result.Failed != 2 {
+		t.Fatalf("succeeded %d failed %d, want 1 and 2", result.Succeeded, result.Failed)
+	}
+
+	saved, err := svc.GetByID(own.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(saved.Tags, []string{"go", "web"}) || saved.Version != own.Version+1 {
+		t.Fatalf("tags %v version %d, want [go web] and version %d", saved.Tags, saved.Version, own.Version+1)
+	}
+	revision, err := svc.revisionRepo.Latest(own.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(revision.Tags, []string{"go", "web"}) {
+		t.Fatalf("revision tags = %v, want the tag change recorded", revision.Tags)
+	}
+	untouched, err := svc.GetByID(others.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(untouched.Tags) != 0 || untouched.Version != others.Version {
+		t.Fatal("a post that failed authorization must not be modified")
+	}
+}
+
+func TestBulkApplyAtomicSkipsAllOnFailure(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	first := createTestPost(t, svc, author, "First")
+	second := createTestPost(t, svc, author, "Second")
+
+	// 前两篇可以修改，第三篇不存在，原子模式下全部不执行
+	req := &model.BulkPostRequest{Action: model.BulkSetStatus, Status: string(model.Private), Atomic: true}
+	result, err := svc.BulkApply(Actor{UserID: author.ID}, req, []uint{first.ID, second.ID, 999}, allowAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{errBulkSkipped.Error(), errBulkSkipped.Error(), "Post not found"}
+	if got := bulkItemErrors(result); !slices.Equal(got, want) {
+		t.Fatalf("items = %q, want %q", got, want)
+	}
+	if result.Succeeded != 0 || result.Failed != 3 {
+		t.Fatalf("succeeded %d failed %d, want 0 and 3", result.Succeeded, result.Failed)
+	}
+	for _, post := range []*model.Post{first, second} {
+		saved, err := svc.GetByID(post.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Status != model.Published {
+			t.Fatalf("post %d status = %s, want unchanged in atomic mode", post.ID, saved.Status)
+		}
+	}
+
+	// 非原子模式下单篇不满足条件只影响该篇：定时发布需要发布时间
+	req.Status = string(model.Scheduled)
+	req.Atomic = false
+	result, err = svc.BulkApply(Actor{UserID: author.ID}, req, []uint{first.ID}, allowAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := bulkItemErrors(result); !slices.Equal(got, []string{ErrInvalidSchedule.Error()}) {
+		t.Fatalf("items = %q, want the schedule error", got)
+	}
+}
+
+func TestBulkApplyRollsBackOnVersionConflict(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	first := createTestPost(t, svc, author, "First")
+	second := createTestPost(t, svc, author, "Second")
+
+	// 在读取之后、保存之前修改第二篇文章，模拟并发编辑
+	authorize := func(post *model.Post) error {
+		if post.ID == second.ID {
+			return db.Model(&model.Post{}).Where("id = ?", post.ID).
+				UpdateColumn("version", gorm.Expr("version + 1")).Error
+		}
+		return nil
+	}
+	req := &model.BulkPostRequest{Action: model.BulkSetStatus, Status: string(model.Private)}
+	result, err := svc.BulkApply(Actor{UserID: author.ID}, req, []uint{first.ID, second.ID}, authorize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ErrPostVersionConflict.Error(), ErrPostVersionConflict.Error()}
+	if got := bulkItemErrors(result); !slices.Equal(got, want) {
+		t.Fatalf("items = %q, want %q", got, want)
+	}
+	saved, err := svc.GetByID(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != model.Published || saved.Version != first.Version {
+		t.Fatal("the transaction should roll back changes to the first post")
+	}
+}
+
+func TestBulkDeleteAndRestore(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	actor := Actor{UserID: author.ID}
+	first := createTestPost(t, svc, author, "First")
+	second := createTestPost(t, svc, author, "Second")
+	ids := []uint{first.ID, second.ID}
+
+	// 不在回收站的文章不能恢复
+	result, err := svc.BulkApply(actor, &model.BulkPostRequest{Action: model.BulkRestore}, ids, allowAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Failed != 2 {
+		t.Fatalf("failed = %d, want 2 for posts not in trash", result.Failed)
+	}
+
+	result, err = svc.BulkApply(actor, &model.BulkPostRequest{Action: model.BulkDelete}, ids, allowAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Succeeded != 2 {
+		t.Fatalf("succeeded = %d, want 2", result.Succeeded)
+	}
+	trashed, err := svc.ResolveBulkIDs(model.BulkRestore, &model.PostQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(trashed, ids) {
+		t.Fatalf("trashed ids = %v, want %v", trashed, ids)
+	}
+
+	result, err = svc.BulkApply(actor, &model.BulkPostRequest{Action: model.BulkRestore}, ids, allowAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Succeeded != 2 {
+		t.Fatalf("succeeded = %d, want 2", result.Succeeded)
+	}
+	for _, id := range ids {
+		if _, err := svc.GetByID(id); err != nil {
+			t.Fatalf("post %d not restored: %v", id, err)
+		}
+	}
+	deletes := 0
+	for _, action := range auditActions(t, db) {
+		if action == model.AuditPostDelete {
+			deletes++
+		}
+	}
+	if deletes != 2 {
+		t.Fatalf("%d delete audit entries, want one per post", deletes)
+	}
+}
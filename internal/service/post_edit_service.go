@@ -0,0 +1,105 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPostLocked  = errors.New("文章正在被其他人编辑")
+	ErrLockNotHeld = errors.New("编辑锁已过期或已被其他人接管")
+)
+
+// PostEditConfig 协同编辑相关的可配置项
+type PostEditConfig struct {
+	// LockTTL 编辑锁的有效期，客户端应在到期前发送心跳续期
+	LockTTL time.Duration
+}
+
+// PostEditService 提供编辑锁和自动保存，二者都不影响文章本身的保存
+type PostEditService struct {
+	editRepo *repository.PostEditRepository
+	config   PostEditConfig
+}
+
+func NewPostEditService(editRepo *repository.PostEditRepository, config PostEditConfig) *PostEditService {
+	return &PostEditService{
+		editRepo: editRepo,
+		config:   config,
+	}
+}
+
+// AcquireLock 获取文章的编辑锁，锁被他人持有且未要求接管时返回当前的锁和 ErrPostLocked
+func (s *PostEditService) AcquireLock(postID uint, userID uuid.UUID, takeover bool) (*model.PostLock, error) {
+	acquired, err := s.editRepo.AcquireLock(postID, userID, time.Now().Add(s.config.LockTTL), takeover)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := s.editRepo.GetLock(postID)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return lock, ErrPostLocked
+	}
+	return lock, nil
+}
+
+// Heartbeat 续期当前用户持有的锁
+func (s *PostEditService) Heartbeat(postID uint, userID uuid.UUID) (*model.PostLock, error) {
+	renewed, err := s.editRepo.RenewLock(postID, userID, time.Now().Add(s.config.LockTTL))
+	if err != nil {
+		return nil, err
+	}
+	if !renewed {
+		return nil, ErrLockNotHeld
+	}
+	return s.editRepo.GetLock(postID)
+}
+
+func (s *PostEditService) ReleaseLock(postID uint, userID uuid.UUID) error {
+	return s.editRepo.ReleaseLock(postID, userID)
+}
+
+// GetLock 读取文章当前的锁，没有人编辑时返回 nil
+func (s *PostEditService) GetLock(postID uint) (*model.PostLock, error) {
+	lock, err := s.editRepo.GetLock(postID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return lock, err
+}
+
+// Autosave 保存当前用户的编辑草稿，覆盖之前的自动保存
+func (s *PostEditService) Autosave(postID uint, userID uuid.UUID, req *model.AutosaveRequest) (*model.PostAutosave, error) {
+	autosave := &model.PostAutosave{
+		PostID:          postID,
+		UserID:          userID,
+		Title:           req.Title,
+		Content:         req.Content,
+		Excerpt:         req.Excerpt,
+		Tags:            req.Tags,
+		MetaTitle:       req.MetaTitle,
+		MetaDescription: req.MetaDescription,
+		BaseVersion:     req.BaseVersion,
+	}
+	if err := s.editRepo.SaveAutosave(autosave); err != nil {
+		return nil, err
+	}
+	return autosave, nil
+}
+
+// GetAutosave 读取当前用户的自动保存草稿，没有时返回 gorm.ErrRecordNotFound
+func (s *PostEditService) GetAutosave(postID uint, userID uuid.UUID) (*model.PostAutosave, error) {
+	return s.editRepo.GetAutosave(postID, userID)
+}
+
+// DiscardAutosave 删除自动保存草稿，文章正式保存后调用
+func (s *PostEditService) DiscardAutosave(postID uint, userID uuid.UUID) error {
+	return s.editRepo.DeleteAutosave(postID, userID)
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func newTestPostEditService(db *gorm.DB) *PostEditService {
+	return NewPostEditService(repository.NewPostEditRepository(db), PostEditConfig{LockTTL: time.Minute})
+}
+
+func TestAcquireLockBlocksOtherEditors(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostEditService(db)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	post := createTestPost(t, newTestPostService(db, PostConfig{}), alice, "Post")
+
+	first, err := svc.AcquireLock(post.ID, alice.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := svc.AcquireLock(post.ID, bob.ID, false)
+	if !errors.Is(err, ErrPostLocked) {
+		t.Fatalf("err = %v, want ErrPostLocked", err)
+	}
+	if lock.UserID != alice.ID || lock.Username != "alice" {
+		t.Fatalf("lock held by %s (%s), want alice", lock.Username, lock.UserID)
+	}
+
+	// 持有者续期时保留原来的获取时间
+	again, err := svc.AcquireLock(post.ID, alice.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.AcquiredAt.Equal(first.AcquiredAt) {
+		t.Fatalf("acquired_at changed from %v to %v on renewal", first.AcquiredAt, again.AcquiredAt)
+	}
+}
+
+func TestAcquireLockTakeover(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostEditService(db)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	post := createTestPost(t, newTestPostService(db, PostConfig{}), alice, "Post")
+
+	if _, err := svc.AcquireLock(post.ID, alice.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := svc.AcquireLock(post.ID, bob.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.UserID != bob.ID {
+		t.Fatalf("lock held by %s, want bob after takeover", lock.Username)
+	}
+	if _, err := svc.Heartbeat(post.ID, alice.ID); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("heartbeat err = %v, want ErrLockNotHeld after takeover", err)
+	}
+	// 被接管的用户释放锁不影响新的持有者
+	if err := svc.ReleaseLock(post.ID, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if lock, err := svc.GetLock(post.ID); err != nil || lock == nil || lock.UserID != bob.ID {
+		t.Fatalf("GetLock = %v, %v; want bob's lock", lock, err)
+	}
+}
+
+func TestAcquireExpiredLock(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostEditService(db)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	post := createTestPost(t, newTestPostService(db, PostConfig{}), alice, "Post")
+
+	if _, err := svc.AcquireLock(post.ID, alice.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&model.PostLock{}).Where("post_id = ?", post.ID).
+		Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if lock, err := svc.GetLock(post.ID); err != nil || lock != nil {
+		t.Fatalf("GetLock = %v, %v; want no lock after expiry", lock, err)
+	}
+	lock, err := svc.AcquireLock(post.ID, bob.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.UserID != bob.ID {
+		t.Fatalf("lock held by %s, want bob", lock.Username)
+	}
+}
+
+func TestAutosaveOverwritesPerUser(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostEditService(db)
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	post := createTestPost(t, newTestPostService(db, PostConfig{}), alice, "Post")
+
+	for _, content := range []string{"first", "second"} {
+		if _, err := svc.Autosave(post.ID, alice.ID, &model.AutosaveRequest{Content: content, BaseVersion: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	autosave, err := svc.GetAutosave(post.ID, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if autosave.Content != "second" {
+		t.Fatalf("autosave = %q, want the latest draft", autosave.Content)
+	}
+	if _, err := svc.GetAutosave(post.ID, bob.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v, want ErrRecordNotFound for another user's draft", err)
+	}
+
+	if err := svc.DiscardAutosave(post.ID, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetAutosave(post.ID, alice.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v, want ErrRecordNotFound after discard", err)
+	}
+}
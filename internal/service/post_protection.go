@@ -0,0 +1,169 @@
+package service
+
+import (
+	"crist-blog/internal/keyset"
+	"crist-blog/internal/model"
+	"crist-blog/internal/ratelimit"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrPostPasswordRequired = errors.New("受密码保护的文章需要设置访问密码")
+	ErrPostNotProtected     = errors.New("文章没有设置密码保护")
+	ErrWrongPostPassword    = errors.New("文章密码错误")
+)
+
+// PostUnlockThrottledError 表示输入文章密码过于频繁，需要等待 RetryAfter 之后再试
+type PostUnlockThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *PostUnlockThrottledError) Error() string {
+	return fmt.Sprintf("尝试次数过多，请 %d 秒后再试", int(e.RetryAfter.Seconds()+0.5))
+}
+
+// HashPostPassword 计算文章访问密码的 bcrypt 哈希
+func HashPostPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// applyProtection 检查受密码保护的文章已设置密码，其他状态的文章清除密码
+func applyProtection(post *model.Post) error {
+	if post.Status != model.Protected {
+		post.PasswordHash = ""
+		return nil
+	}
+	if post.PasswordHash == "" {
+		return ErrPostPasswordRequired
+	}
+	return nil
+}
+
+// passwordFingerprint 密码哈希的指纹，写入解锁令牌，修改密码后旧令牌随之失效
+func passwordFingerprint(hash string) string {
+	sum := sha256.Sum256([]byte(hash))
+	return hex.EncodeToString(sum[:8])
+}
+
+// PostUnlockConfig 文章解锁相关的可配置项
+type PostUnlockConfig struct {
+	TokenTTL    time.Duration // 解锁令牌的有效期
+	MaxAttempts int           // 每个 IP 对每篇文章在 Window 内允许的失败次数
+	// MaxPostAttempts 每篇文章在 Window 内的失败总次数达到该值后（不区分 IP），
+	// 对该文章的密码校验改为按 PostAttemptInterval 排队进行，限制轮换 IP 穷举密码的速度
+	MaxPostAttempts int
+	// PostAttemptInterval 排队时相邻两次校验的最小间隔
+	PostAttemptInterval time.Duration
+	// MaxPostWait 排队等待的上限，超过时返回 *PostUnlockThrottledError
+	MaxPostWait time.Duration
+}
+
+// PostUnlockService 校验文章密码并签发只对该文章有效的解锁令牌
+type PostUnlockService struct {
+	keys     *keyset.KeySet
+	failures *ratelimit.SlidingWindow
+	config   PostUnlockConfig
+
+	mu          sync.Mutex
+	nextAttempt map[string]time.Time // 排队中的文章下一次允许校验的时间
+}
+
+func NewPostUnlockService(keys *keyset.KeySet, failures *ratelimit.SlidingWindow, config PostUnlockConfig) *PostUnlockService {
+	return &PostUnlockService{
+		keys:        keys,
+		failures:    failures,
+		config:      config,
+		nextAttempt: make(map[string]time.Time),
+	}
+}
+
+// Unlock 校验密码，通过后返回解锁令牌及其过期时间
+// 按 IP+文章统计失败次数，超过 MaxAttempts 时返回 *PostUnlockThrottledError，校验前先预占一次失败，
+// 密码正确时再归还，避免并发请求同时通过检查。
+// 文章失败总次数只用于排队限速而不直接拒绝，知道密码的读者不会因为他人输错而无法打开文章
+func (s *PostUnlockService) Unlock(post *model.Post, password, ip string) (string, time.Time, error) {
+	if post.Status != model.Protected {
+		return "", time.Time{}, ErrPostNotProtected
+	}
+	id := strconv.FormatUint(uint64(post.ID), 10)
+	postKey := "post|" + id
+	ipKey := ip + "|" + id
+	if wait := s.failures.Allow(ipKey, s.config.MaxAttempts); wait > 0 {
+		return "", time.Time{}, &PostUnlockThrottledError{RetryAfter: wait}
+	}
+	if s.failures.Count(postKey) >= s.config.MaxPostAttempts {
+		wait, ok := s.reserveAttempt(postKey)
+		if !ok {
+			s.failures.Release(ipKey)
+			return "", time.Time{}, &PostUnlockThrottledError{RetryAfter: wait}
+		}
+		time.Sleep(wait)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(post.PasswordHash), []byte(password)) != nil {
+		s.failures.Add(postKey)
+		return "", time.Time{}, ErrWrongPostPassword
+	}
+	s.failures.Release(ipKey)
+
+	now := time.Now()
+	expiresAt := now.Add(s.config.TokenTTL)
+	token, err := s.keys.Sign(jwt.MapClaims{
+		"typ":     "post_unlock",
+		"post_id": post.ID,
+		"pwd":     passwordFingerprint(post.PasswordHash),
+		"iat":     now.Unix(),
+		"exp":     expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// reserveAttempt 为排队中的文章预约下一次校验，返回需要等待的时长
+// 等待超过 MaxPostWait 时不预约，返回 false 和建议的重试间隔
+func (s *PostUnlockService) reserveAttempt(key string) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	slot := now
+	if next, ok := s.nextAttempt[key]; ok && next.After(now) {
+		slot = next
+	}
+	wait := slot.Sub(now)
+	if wait > s.config.MaxPostWait {
+		return wait - s.config.MaxPostWait, false
+	}
+	s.nextAttempt[key] = slot.Add(s.config.PostAttemptInterval)
+	return wait, true
+}
+
+// CanView 判断读者能否查看文章正文：未受密码保护的文章总是可以，受保护的文章需要有效的解锁令牌
+func (s *PostUnlockService) CanView(post *model.Post, tokenString string) bool {
+	if post.Status != model.Protected {
+		return true
+	}
+	if tokenString == "" {
+		return false
+	}
+	token, err := jwt.Parse(tokenString, s.keys.Keyfunc, jwt.WithValidMethods(s.keys.ValidMethods()))
+	if err != nil || !token.Valid {
+		return false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != "post_unlock" {
+		return false
+	}
+	postID, _ := claims["post_id"].(float64)
+	return uint(postID) == post.ID && claims["pwd"] == passwordFingerprint(post.PasswordHash)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/ratelimit"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestUnlockService(t *testing.T, config PostUnlockConfig) (*PostUnlockService, *model.Post) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.TokenTTL = time.Hour
+	svc := NewPostUnlockService(newTestKeySet(t), ratelimit.NewSlidingWindow(time.Hour), config)
+	return svc, &model.Post{ID: 7, Status: model.Protected, PasswordHash: string(hash)}
+}
+
+func TestPostUnlockTokenGrantsAccess(t *testing.T) {
+	svc, post := newTestUnlockService(t, PostUnlockConfig{MaxAttempts: 5, MaxPostAttempts: 50})
+	token, _, err := svc.Unlock(post, "secret", "1.2.3.4")
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if !svc.CanView(post, token) {
+		t.Fatal("token should grant access to the post")
+	}
+	other := *post
+	other.ID = 8
+	if svc.CanView(&other, token) {
+		t.Fatal("token must not grant access to another post")
+	}
+}
+
+func TestPostUnlockPerIPLimit(t *testing.T) {
+	svc, post := newTestUnlockService(t, PostUnlockConfig{MaxAttempts: 2, MaxPostAttempts: 50})
+	for i := 0; i < 2; i++ {
+		if _, _, err := svc.Unlock(post, "wrong", "1.2.3.4"); !errors.Is(err, ErrWrongPostPassword) {
+			t.Fatalf("attempt %d: got %v, want ErrWrongPostPassword", i+1, err)
+		}
+	}
+	var throttled *PostUnlockThrottledError
+	if _, _, err := svc.Unlock(post, "secret", "1.2.3.4"); !errors.As(err, &throttled) {
+		t.Fatalf("got %v, want *PostUnlockThrottledError", err)
+	}
+	if _, _, err := svc.Unlock(post, "secret", "5.6.7.8"); err != nil {
+		t.Fatalf("other IP should not be throttled: %v", err)
+	}
+}
+
+func TestPostUnlockPerPostLimitQueuesAttempts(t *testing.T) {
+	svc, post := newTestUnlockService(t, PostUnlockConfig{
+		MaxAttempts:         5,
+		MaxPostAttempts:     3,
+		PostAttemptInterval: time.Hour,
+	})
+	for i := 0; i < 3; i++ {
+		ip := fmt.Sprintf("10.0.0.%d", i)
+		if _, _, err := svc.Unlock(post, "wrong", ip); !errors.Is(err, ErrWrongPostPassword) {
+			t.Fatalf("attempt %d: got %v, want ErrWrongPostPassword", i+1, err)
+		}
+	}
+	// 超过文章失败总次数后，知道密码的读者仍然可以解锁
+	if _, _, err := svc.Unlock(post, "secret", "10.0.0.99"); err != nil {
+		t.Fatalf("correct password after post limit: %v", err)
+	}
+	// 但其它 IP 的校验需要排队，等待超过 MaxPostWait 时返回限流错误
+	var throttled *PostUnlockThrottledError
+	if _, _, err := svc.Unlock(post, "wrong", "10.0.0.100"); !errors.As(err, &throttled) {
+		t.Fatalf("got %v, want *PostUnlockThrottledError", err)
+	}
+}
+
+func TestPostUnlockQueuedAttemptsAreSpaced(t *testing.T) {
+	const interval = 30 * time.Millisecond
+	svc, post := newTestUnlockService(t, PostUnlockConfig{
+		MaxAttempts:         5,
+		MaxPostAttempts:     1,
+		PostAttemptInterval: interval,
+		MaxPostWait:         time.Second,
+	})
+	if _, _, err := svc.Unlock(post, "wrong", "10.0.0.1"); !errors.Is(err, ErrWrongPostPassword) {
+		t.Fatalf("got %v, want ErrWrongPostPassword", err)
+	}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, _, err := svc.Unlock(post, "secret", fmt.Sprintf("10.0.1.%d", i)); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+	// 第一次立即校验，之后每次间隔 interval
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Fatalf("3 queued attempts took %s, want at least %s", elapsed, 2*interval)
+	}
+}
+
+func TestPostUnlockSuccessDoesNotConsumeAttempts(t *testing.T) {
+	svc, post := newTestUnlockService(t, PostUnlockConfig{MaxAttempts: 1, MaxPostAttempts: 1})
+	for i := 0; i < 3; i++ {
+		if _, _, err := svc.Unlock(post, "secret", "1.2.3.4"); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestPostUnlockConcurrentAttemptsRespectLimit(t *testing.T) {
+	svc, post := newTestUnlockService(t, PostUnlockConfig{MaxAttempts: 3, MaxPostAttempts: 50})
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		checked int
+	)
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := svc.Unlock(post, "wrong", "1.2.3.4"); errors.Is(err, ErrWrongPostPassword) {
+				mu.Lock()
+				checked++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if checked != 3 {
+		t.Fatalf("%d passwords were checked, want 3", checked)
+	}
+}
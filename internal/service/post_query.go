@@ -0,0 +1,104 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrInvalidPostSort = errors.New("排序字段只能是 published_at、views 或 likes")
+)
+
+// QueryPosts 分页查询文章摘要
+// cursor 不为空时使用游标分页，否则按 page 做偏移分页；多取一条用于判断是否还有下一页
+func (s *PostService) QueryPosts(q *model.PostQuery, page int, cursor string) (*model.PostPage, error) {
+	if q.Sort == "" {
+		q.Sort = model.PostSortPublishedAt
+	}
+	if q.Sort != model.PostSortPublishedAt && q.Sort != model.PostSortViews && q.Sort != model.PostSortLikes {
+		return nil, ErrInvalidPostSort
+	}
+	pageSize := q.Limit
+	if cursor != "" {
+		after, err := decodePostCursor(cursor, q.Sort)
+		if err != nil {
+			return nil, err
+		}
+		q.After = after
+		page = 0
+	} else {
+		q.Offset = (page - 1) * pageSize
+	}
+	q.Limit = pageSize + 1
+
+	items, total, err := s.PostRepo.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.PostPage{
+		Items:    items,
+		Total:    total,
+		PageSize: pageSize,
+		Page:     page,
+	}
+	if len(items) > pageSize {
+		result.Items = items[:pageSize]
+		result.HasMore = true
+		result.NextCursor = encodePostCursor(q.Sort, result.Items[pageSize-1])
+	}
+	return result, nil
+}
+
+// encodePostCursor 将最后一篇文章的排序值和ID编码为不透明的游标
+func encodePostCursor(sort string, last *model.PostSummary) string {
+	c := model.PostCursor{Sort: sort, ID: last.ID}
+	switch sort {
+	case model.PostSortViews:
+		c.Value = last.Views
+	case model.PostSortLikes:
+		c.Value = last.Likes
+	default:
+		date := last.CreatedAt
+		if last.PublishedAt != nil {
+			date = *last.PublishedAt
+		}
+		c.Value = date.Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePostCursor 解析游标，游标必须与当前排序字段一致
+func decodePostCursor(cursor, sort string) (*model.PostCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c model.PostCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+	switch v := c.Value.(type) {
+	case float64:
+		if sort == model.PostSortPublishedAt {
+			return nil, ErrInvalidCursor
+		}
+		c.Value = int64(v)
+	case string:
+		if sort != model.PostSortPublishedAt {
+			return nil, ErrInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		c.Value = t
+	default:
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPostCursorRoundTrip(t *testing.T) {
+	published := time.Date(2024, 3, 1, 8, 30, 0, 123456789, time.FixedZone("CST", 8*3600))
+	created := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	last := &model.PostSummary{ID: 42, Views: 1000, Likes: 7, PublishedAt: &published, CreatedAt: created}
+
+	cases := []struct {
+		sort string
+		want interface{}
+	}{
+		{model.PostSortViews, int64(1000)},
+		{model.PostSortLikes, int64(7)},
+		{model.PostSortPublishedAt, published},
+	}
+	for _, tc := range cases {
+		cursor, err := decodePostCursor(encodePostCursor(tc.sort, last), tc.sort)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", tc.sort, err)
+		}
+		if cursor.ID != 42 || cursor.Sort != tc.sort {
+			t.Fatalf("%s: got id %d sort %q", tc.sort, cursor.ID, cursor.Sort)
+		}
+		if want, ok := tc.want.(time.Time); ok {
+			// 时间值保留纳秒精度，与数据库中的 published_at 精确比较
+			if got, ok := cursor.Value.(time.Time); !ok || !got.Equal(want) {
+				t.Fatalf("%s: value = %v, want %v", tc.sort, cursor.Value, want)
+			}
+			continue
+		}
+		if cursor.Value != tc.want {
+			t.Fatalf("%s: value = %#v, want %#v", tc.sort, cursor.Value, tc.want)
+		}
+	}
+}
+
+func TestPostCursorFallsBackToCreatedAt(t *testing.T) {
+	created := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	last := &model.PostSummary{ID: 1, CreatedAt: created}
+	cursor, err := decodePostCursor(encodePostCursor(model.PostSortPublishedAt, last), model.PostSortPublishedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := cursor.Value.(time.Time); !ok || !got.Equal(created) {
+		t.Fatalf("value = %v, want %v", cursor.Value, created)
+	}
+}
+
+func TestDecodePostCursorRejectsInvalidInput(t *testing.T) {
+	last := &model.PostSummary{ID: 1, Views: 3}
+	viewsCursor := encodePostCursor(model.PostSortViews, last)
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	cases := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{"not base64", "!!!", model.PostSortViews},
+		{"not json", raw("nope"), model.PostSortViews},
+		{"sort changed", viewsCursor, model.PostSortLikes},
+		{"string value for numeric sort", raw(`{"s":"views","v":"3","id":1}`), model.PostSortViews},
+		{"numeric value for date sort", raw(`{"s":"published_at","v":3,"id":1}`), model.PostSortPublishedAt},
+		{"malformed date", raw(`{"s":"published_at","v":"yesterday","id":1}`), model.PostSortPublishedAt},
+		{"missing value", raw(`{"s":"views","id":1}`), model.PostSortViews},
+	}
+	for _, tc := range cases {
+		if _, err := decodePostCursor(tc.cursor, tc.sort); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: got %v, want ErrInvalidCursor", tc.name, err)
+		}
+	}
+}
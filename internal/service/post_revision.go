@@ -0,0 +1,107 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// diffContextLines 差异中每处改动前后保留的上下文行数
+const diffContextLines = 3
+
+// recordRevision 保存文章快照并清理超出数量限制的旧版本，失败只记录日志，不影响文章保存
+func (s *PostService) recordRevision(actor Actor, post *model.Post) {
+	if err := s.revisionRepo.Create(model.NewPostRevision(post, actor.UserID)); err != nil {
+		log.Printf("warning: failed to record revision of post %d: %v", post.ID, err)
+		return
+	}
+	if s.config.RevisionLimit > 0 {
+		if err := s.revisionRepo.Prune(post.ID, s.config.RevisionLimit); err != nil {
+			log.Printf("warning: failed to prune revisions of post %d: %v", post.ID, err)
+		}
+	}
+}
+
+func (s *PostService) ListRevisions(postID uint) ([]*model.PostRevisionSummary, error) {
+	return s.revisionRepo.ListByPostID(postID)
+}
+
+func (s *PostService) GetRevision(postID, revisionID uint) (*model.PostRevision, error) {
+	return s.revisionRepo.GetByID(postID, revisionID)
+}
+
+// revisionText 把版本渲染为便于逐行比较的文本，元数据在前，正文在后
+func revisionText(r *model.PostRevision) []string {
+	header := fmt.Sprintf("Title: %s\nExcerpt: %s\nTags: %s\nMeta-Title: %s\nMeta-Description: %s\n\n",
+		r.Title, r.Excerpt, strings.Join(r.Tags, ", "), r.MetaTitle, r.MetaDescription)
+	return difflib.SplitLines(header + r.Content)
+}
+
+// DiffRevisions 生成两个版本之间的统一格式差异，内容相同时 Diff 为空
+func (s *PostService) DiffRevisions(postID, fromID, toID uint) (*model.PostRevisionDiff, error) {
+	from, err := s.revisionRepo.GetByID(postID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.revisionRepo.GetByID(postID, toID)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        revisionText(from),
+		B:        revisionText(to),
+		FromFile: fmt.Sprintf("revision %d", from.ID),
+		ToFile:   fmt.Sprintf("revision %d", to.ID),
+		FromDate: from.CreatedAt.Format("2006-01-02 15:04:05"),
+		ToDate:   to.CreatedAt.Format("2006-01-02 15:04:05"),
+		Context:  diffContextLines,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.PostRevisionDiff{From: from.ID, To: to.ID, Diff: diff}, nil
+}
+
+// RestoreRevision 用历史版本的内容覆盖文章，slug、状态和分类保持不变
+// 恢复本身也会记录为一个新版本，因此可以撤销
+// 与 Update 相同，version 必须是客户端看到的文章当前版本，为 0 时返回 ErrPostPreconditionRequired
+func (s *PostService) RestoreRevision(actor Actor, postID, revisionID uint, version int) (post *model.Post, err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditPostRevisionRestore, "post", strconv.FormatUint(uint64(postID), 10), err)
+	}()
+	if version == 0 {
+		return nil, ErrPostPreconditionRequired
+	}
+	revision, err := s.revisionRepo.GetByID(postID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	post, err = s.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.Version != version {
+		return nil, ErrPostVersionConflict
+	}
+	post.Title = revision.Title
+	post.Content = revision.Content
+	post.Excerpt = revision.Excerpt
+	post.Tags = revision.Tags
+	post.MetaTitle = revision.MetaTitle
+	post.MetaDescription = revision.MetaDescription
+	if err := s.PostRepo.Update(post, ""); err != nil {
+		if errors.Is(err, repository.ErrStaleVersion) {
+			return nil, ErrPostVersionConflict
+		}
+		return nil, err
+	}
+	s.reindex(post)
+	s.recordRevision(actor, post)
+	return post, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestRevisionsArePrunedToLimit(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{RevisionLimit: 3})
+	author := createTestUser(t, db, "alice")
+	actor := Actor{UserID: author.ID}
+	post := createTestPost(t, svc, author, "Post")
+	for _, content := range []string{"v2", "v3", "v4", "v5"} {
+		post.Content = content
+		if err := svc.Update(actor, post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revisions, err := svc.ListRevisions(post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, summary := range revisions {
+		revision, err := svc.GetRevision(post.ID, summary.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, revision.Content)
+	}
+	if got := strings.Join(contents, ","); got != "v5,v4,v3" {
+		t.Fatalf("revisions = %s, want the newest three, newest first", got)
+	}
+	if revisions[0].Username == nil || *revisions[0].Username != "alice" {
+		t.Fatalf("revision author = %v, want alice", revisions[0].Username)
+	}
+}
+
+func TestDiffRevisions(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	post := createTestPost(t, svc, author, "Post")
+	first, err := svc.revisionRepo.Latest(post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post.Title = "Renamed"
+	post.Content = "line one\nline two\n"
+	if err := svc.Update(Actor{UserID: author.ID}, post); err != nil {
+		t.Fatal(err)
+	}
+	second, err := svc.revisionRepo.Latest(post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := svc.DiffRevisions(post.ID, first.ID, second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"-Title: Post\n", "+Title: Renamed\n", "-Post content", "+line one\n", "+line two\n"} {
+		if !strings.Contains(diff.Diff, line) {
+			t.Errorf("diff does not contain %q:\n%s", line, diff.Diff)
+		}
+	}
+
+	same, err := svc.DiffRevisions(post.ID, second.ID, second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same.Diff != "" {
+		t.Fatalf("diff of identical revisions = %q, want empty", same.Diff)
+	}
+
+	other := createTestPost(t, svc, author, "Other")
+	if _, err := svc.DiffRevisions(other.ID, first.ID, second.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v, want ErrRecordNotFound for revisions of another post", err)
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	actor := Actor{UserID: author.ID}
+	post := createTestPost(t, svc, author, "Original")
+	original, err := svc.revisionRepo.Latest(post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post.Title = "Edited"
+	post.Content = "edited"
+	post.Slug = "edited"
+	if err := svc.Update(actor, post); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.RestoreRevision(actor, post.ID, original.ID, 0); !errors.Is(err, ErrPostPreconditionRequired) {
+		t.Fatalf("err = %v, want ErrPostPreconditionRequired without a version", err)
+	}
+	if _, err := svc.RestoreRevision(actor, post.ID, original.ID, post.Version-1); !errors.Is(err, ErrPostVersionConflict) {
+		t.Fatalf("err = %v, want ErrPostVersionConflict for a stale version", err)
+	}
+
+	restored, err := svc.RestoreRevision(actor, post.ID, original.ID, post.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Title != "Original" || restored.Content != "Original content" {
+		t.Fatalf("restored = %q/%q, want the original title and content", restored.Title, restored.Content)
+	}
+	if restored.Slug != "edited" || restored.Version != post.Version+1 {
+		t.Fatalf("restored slug %q version %d, want slug kept and version %d", restored.Slug, restored.Version, post.Version+1)
+	}
+	latest, err := svc.revisionRepo.Latest(post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.ID == original.ID || latest.Title != "Original" {
+		t.Fatal("restore should be recorded as a new revision")
+	}
+	actions := auditActions(t, db)
+	var restores []string
+	for _, action := range actions {
+		if action == model.AuditPostRevisionRestore {
+			restores = append(restores, action)
+		}
+	}
+	if len(restores) != 3 {
+		t.Fatalf("audit = %v, want every restore attempt recorded", actions)
+	}
+}
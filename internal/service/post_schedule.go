@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"crist-blog/internal/model"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidSchedule = errors.New("定时发布需要设置晚于当前时间的发布时间")
+
+// publishBatchSize 定时发布任务每个事务处理的文章数
+const publishBatchSize = 100
+
+// systemActor 后台任务在审计日志中的操作者
+var systemActor = Actor{Username: "system"}
+
+// applySchedule 根据状态和发布时间整理文章的发布信息：
+// 已发布但发布时间在将来的文章改为定时发布，已发布或受密码保护但没有发布时间的文章使用当前时间
+func applySchedule(post *model.Post, now time.Time) error {
+	switch post.Status {
+	case model.Scheduled:
+		if post.PublishedAt == nil || !post.PublishedAt.After(now) {
+			return ErrInvalidSchedule
+		}
+	case model.Published:
+		if post.PublishedAt == nil {
+			post.PublishedAt = &now
+		} else if post.PublishedAt.After(now) {
+			post.Status = model.Scheduled
+		}
+	case model.Protected:
+		if post.PublishedAt == nil {
+			post.PublishedAt = &now
+		}
+	}
+	return nil
+}
+
+// PublishDuePosts 发布所有已到时间的定时文章，由后台调度器定期调用
+func (s *PostService) PublishDuePosts(ctx context.Context) error {
+	for ctx.Err() == nil {
+		posts, err := s.PostRepo.PublishDue(time.Now(), publishBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, post := range posts {
+			s.audit.Record(systemActor, model.AuditPostScheduledPublish, "post", strconv.FormatUint(uint64(post.ID), 10), nil)
+		}
+		if len(posts) < publishBatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
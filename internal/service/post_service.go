@@ -3,55 +3,178 @@ package service
 import (
 	"crist-blog/internal/model"
 	"crist-blog/internal/repository"
+	"errors"
+	"log"
+	"strconv"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPostVersionConflict      = errors.New("文章已被其他人修改，请刷新后重试")
+	ErrPostPreconditionRequired = errors.New("修改文章需要通过 If-Match 头或 version 字段提供当前版本")
 )
 
+// PostConfig 文章相关的可配置项
+type PostConfig struct {
+	// RevisionLimit 每篇文章保留的版本数，0 表示不限制
+	RevisionLimit int
+	// TrashRetention 文章在回收站中保留的时间，超过后永久删除，0 表示不自动删除
+	TrashRetention time.Duration
+}
+
 type PostService struct {
-	PostRepo *repository.PostRepository
+	PostRepo     *repository.PostRepository
+	revisionRepo *repository.PostRevisionRepository
+	search       *SearchService
+	audit        *AuditService
+	config       PostConfig
 }
 
-func NewPostService(postRepo *repository.PostRepository) *PostService {
+func NewPostService(postRepo *repository.PostRepository, revisionRepo *repository.PostRevisionRepository, search *SearchService, audit *AuditService, config PostConfig) *PostService {
 	return &PostService{
-		PostRepo: postRepo,
+		PostRepo:     postRepo,
+		revisionRepo: revisionRepo,
+		search:       search,
+		audit:        audit,
+		config:       config,
 	}
 }
 
-func (s *PostService) CreatePost(post *model.Post) error {
-	if post.Status == model.Published && post.PublishedAt == nil {
-		now := time.Now()
-		post.PublishedAt = &now
+// reindex 更新文章的检索向量，失败只影响搜索结果，不影响文章保存
+func (s *PostService) reindex(post *model.Post) {
+	if err := s.search.IndexPost(post); err != nil {
+		log.Printf("warning: failed to update search vector of post %d: %v", post.ID, err)
 	}
-	return s.PostRepo.CreatePost(post)
+}
+
+func (s *PostService) CreatePost(actor Actor, post *model.Post) error {
+	if err := applySchedule(post, time.Now()); err != nil {
+		return err
+	}
+	if err := applyProtection(post); err != nil {
+		return err
+	}
+	if err := s.assignSlug(post); err != nil {
+		return err
+	}
+	post.Version = 1
+	err := s.PostRepo.CreatePost(post)
+	s.audit.Record(actor, model.AuditPostCreate, "post", strconv.FormatUint(uint64(post.ID), 10), err)
+	if err == nil {
+		s.reindex(post)
+		s.recordRevision(actor, post)
+	}
+	return err
 }
 
 func (s *PostService) GetByID(id uint) (*model.Post, error) {
 	return s.PostRepo.GetByID(id)
 }
 
-func (s *PostService) Update(post *model.Post) error {
+// isListed 已发布和受密码保护的文章对读者可见，后者还需要解锁才能查看正文
+func isListed(post *model.Post) bool {
+	return post.Status == model.Published || post.Status == model.Protected
+}
+
+// GetPublishedByID 读取公开可见的文章，草稿、私密和未到时间的定时文章返回 gorm.ErrRecordNotFound
+func (s *PostService) GetPublishedByID(id uint) (*model.Post, error) {
+	post, err := s.PostRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !isListed(post) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return post, nil
+}
+
+// GetPublishedBySlug 按 slug 查找已发布的文章
+// slug 是文章用过的旧 slug 时返回 redirect 为当前 slug，由调用方重定向
+func (s *PostService) GetPublishedBySlug(slug string) (post *model.Post, redirect string, err error) {
+	post, err = s.PostRepo.GetBySlug(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		post, err = s.PostRepo.GetBySlugHistory(slug)
+		if err == nil && isListed(post) {
+			return nil, post.Slug, nil
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if !isListed(post) {
+		return nil, "", gorm.ErrRecordNotFound
+	}
+	return post, "", nil
+}
+
+// Update 修改文章，post.Version 必须与文章当前版本一致，否则返回 ErrPostVersionConflict
+// 未提供版本（为 0）时返回 ErrPostPreconditionRequired，避免客户端在不知情时覆盖他人的修改
+// 保存成功后 post.Version 为新的版本号
+func (s *PostService) Update(actor Actor, post *model.Post) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditPostUpdate, "post", strconv.FormatUint(uint64(post.ID), 10), err)
+	}()
+	if post.Version == 0 {
+		return ErrPostPreconditionRequired
+	}
 	existing, err := s.GetByID(post.ID)
 	if err != nil {
 		return err
 	}
+	if post.Version != existing.Version {
+		return ErrPostVersionConflict
+	}
+	// 未提供 slug 时保留原 slug，避免修改标题导致链接变化
+	previousSlug := existing.Slug
+	if post.Slug != "" && post.Slug != existing.Slug {
+		existing.Slug = post.Slug
+		if err := s.assignSlug(existing); err != nil {
+			return err
+		}
+	}
 	existing.Title = post.Title
-	existing.Slug = post.Slug
 	existing.Content = post.Content
 	existing.Excerpt = post.Excerpt
-	existing.Status = post.Status
 	existing.CategoryID = post.CategoryID
 	existing.Tags = post.Tags
 	existing.MetaTitle = post.MetaTitle
 	existing.MetaDescription = post.MetaDescription
 
-	if existing.Status == model.Published && existing.PublishedAt == nil {
-		now := time.Now()
-		existing.PublishedAt = &now
+	if post.PublishedAt != nil {
+		existing.PublishedAt = post.PublishedAt
+	} else if post.Status == model.Published && existing.Status == model.Scheduled {
+		// 定时文章改为立即发布
+		existing.PublishedAt = nil
 	}
-	return s.PostRepo.Update(existing)
+	existing.Status = post.Status
+	if err := applySchedule(existing, time.Now()); err != nil {
+		return err
+	}
+	// 未提供新密码时保留原密码
+	if post.PasswordHash != "" {
+		existing.PasswordHash = post.PasswordHash
+	}
+	if err := applyProtection(existing); err != nil {
+		return err
+	}
+	if err := s.PostRepo.Update(existing, previousSlug); err != nil {
+		if errors.Is(err, repository.ErrStaleVersion) {
+			return ErrPostVersionConflict
+		}
+		return err
+	}
+	post.Version = existing.Version
+	s.reindex(existing)
+	s.recordRevision(actor, existing)
+	return nil
 }
 
-func (s *PostService) Delete(id uint) error {
-	return s.PostRepo.Delete(id)
+func (s *PostService) Delete(actor Actor, id uint) error {
+	err := s.PostRepo.Delete(id)
+	s.audit.Record(actor, model.AuditPostDelete, "post", strconv.FormatUint(uint64(id), 10), err)
+	return err
 }
 
 func (s *PostService) List() ([]*model.Post, error) {
@@ -59,6 +182,10 @@ func (s *PostService) List() ([]*model.Post, error) {
 	return s.PostRepo.List()
 }
 
+func (s *PostService) ListPublished() ([]*model.PostSummary, error) {
+	return s.PostRepo.ListPublishedSummaries()
+}
+
 func (s *PostService) GetHotPosts() ([]*model.HotPost, error) {
 	return s.PostRepo.GetHotPost()
 }
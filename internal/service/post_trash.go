@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"crist-blog/internal/model"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+)
+
+var ErrPostNotInTrash = errors.New("文章不在回收站中")
+
+// purgeBatchSize 回收站清理任务每条语句删除的文章数
+const purgeBatchSize = 100
+
+// ListTrash 分页列出回收站中的文章
+func (s *PostService) ListTrash(page, pageSize int) ([]*model.TrashedPost, int64, error) {
+	return s.PostRepo.ListTrashed((page-1)*pageSize, pageSize)
+}
+
+// RestorePost 从回收站恢复文章，恢复后保持删除前的状态和 slug
+func (s *PostService) RestorePost(actor Actor, id uint) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditPostRestore, "post", strconv.FormatUint(uint64(id), 10), err)
+	}()
+	restored, err := s.PostRepo.Restore(id)
+	if err != nil {
+		return err
+	}
+	if restored == 0 {
+		return ErrPostNotInTrash
+	}
+	return nil
+}
+
+// PurgePost 永久删除回收站中的文章，不可恢复
+func (s *PostService) PurgePost(actor Actor, id uint) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditPostPurge, "post", strconv.FormatUint(uint64(id), 10), err)
+	}()
+	purged, err := s.PostRepo.Purge(id)
+	if err != nil {
+		return err
+	}
+	if purged == 0 {
+		return ErrPostNotInTrash
+	}
+	return nil
+}
+
+// PurgeExpiredTrash 永久删除超过保留期的回收站文章，由后台调度器定期调用
+func (s *PostService) PurgeExpiredTrash(ctx context.Context) error {
+	if s.config.TrashRetention <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-s.config.TrashRetention)
+	for ctx.Err() == nil {
+		ids, err := s.PostRepo.PurgeTrashedBefore(cutoff, purgeBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			s.audit.Record(systemActor, model.AuditPostPurge, "post", strconv.FormatUint(uint64(id), 10), nil)
+		}
+		if len(ids) > 0 {
+			log.Printf("purged %d posts from trash", len(ids))
+		}
+		if len(ids) < purgeBatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"errors"
+	"testing"
+)
+
+func TestRestorePostFromTrash(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	actor := Actor{UserID: author.ID}
+	post := createTestPost(t, svc, author, "Post")
+
+	if err := svc.RestorePost(actor, post.ID); !errors.Is(err, ErrPostNotInTrash) {
+		t.Fatalf("err = %v, want ErrPostNotInTrash for a live post", err)
+	}
+	if err := svc.Delete(actor, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	trashed, total, err := svc.ListTrash(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(trashed) != 1 || trashed[0].ID != post.ID || trashed[0].DeletedAt.IsZero() {
+		t.Fatalf("trash = %v (total %d), want the deleted post", trashed, total)
+	}
+
+	if err := svc.RestorePost(actor, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	restored, _, err := svc.GetPublishedBySlug(post.Slug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Status != model.Published {
+		t.Fatalf("status = %s, want the status before deletion", restored.Status)
+	}
+	if _, total, err := svc.ListTrash(1, 10); err != nil || total != 0 {
+		t.Fatalf("trash total = %d, %v; want empty", total, err)
+	}
+}
+
+func TestPurgePostRemovesHistory(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	actor := Actor{UserID: author.ID}
+	post := createTestPost(t, svc, author, "Post")
+	post.Slug = "renamed"
+	if err := svc.Update(actor, post); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.PurgePost(actor, post.ID); !errors.Is(err, ErrPostNotInTrash) {
+		t.Fatalf("err = %v, want ErrPostNotInTrash: only trashed posts can be purged", err)
+	}
+	if err := svc.Delete(actor, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.PurgePost(actor, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.RestorePost(actor, post.ID); !errors.Is(err, ErrPostNotInTrash) {
+		t.Fatalf("err = %v, want ErrPostNotInTrash after purge", err)
+	}
+
+	for _, table := range []interface{}{&model.Post{}, &model.PostRevision{}, &model.PostSlug{}} {
+		var count int64
+		if err := db.Unscoped().Model(table).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Fatalf("%T: %d rows left after purge", table, count)
+		}
+	}
+	// 永久删除后 slug 可以再次使用
+	if slug := createTestPost(t, svc, author, "Post").Slug; slug != "post" {
+		t.Fatalf("slug = %q, want post", slug)
+	}
+}
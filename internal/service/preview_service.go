@@ -0,0 +1,123 @@
+package service
+
+import (
+	"crist-blog/internal/keyset"
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPreviewToken = errors.New("预览链接无效或已过期")
+	ErrPreviewTTLTooLong   = errors.New("预览链接有效期超过上限")
+)
+
+// PreviewConfig 预览链接相关的可配置项
+type PreviewConfig struct {
+	BaseURL    string        // 前端地址，预览链接为 BaseURL/preview/<token>
+	DefaultTTL time.Duration // 未指定有效期时使用
+	MaxTTL     time.Duration
+}
+
+// PreviewService 签发和校验文章预览链接
+// 链接是签名的 JWT，绑定文章和版本，不落库，只能等待过期失效
+type PreviewService struct {
+	postRepo     *repository.PostRepository
+	revisionRepo *repository.PostRevisionRepository
+	keys         *keyset.KeySet
+	audit        *AuditService
+	config       PreviewConfig
+}
+
+func NewPreviewService(postRepo *repository.PostRepository, revisionRepo *repository.PostRevisionRepository, keys *keyset.KeySet, audit *AuditService, config PreviewConfig) *PreviewService {
+	return &PreviewService{
+		postRepo:     postRepo,
+		revisionRepo: revisionRepo,
+		keys:         keys,
+		audit:        audit,
+		config:       config,
+	}
+}
+
+// CreateLink 为文章的某个版本签发预览链接，revisionID 为 0 时使用最新版本
+func (s *PreviewService) CreateLink(actor Actor, postID, revisionID uint, ttl time.Duration) (link *model.PreviewLink, err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditPostPreviewLink, "post", strconv.FormatUint(uint64(postID), 10), err)
+	}()
+	if ttl <= 0 {
+		ttl = s.config.DefaultTTL
+	}
+	if ttl > s.config.MaxTTL {
+		return nil, ErrPreviewTTLTooLong
+	}
+	var revision *model.PostRevision
+	if revisionID == 0 {
+		revision, err = s.revisionRepo.Latest(postID)
+	} else {
+		revision, err = s.revisionRepo.GetByID(postID, revisionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	token, err := s.keys.Sign(jwt.MapClaims{
+		"typ":         "preview",
+		"jti":         uuid.New().String(),
+		"post_id":     postID,
+		"revision_id": revision.ID,
+		"iat":         now.Unix(),
+		"exp":         expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.PreviewLink{
+		Token:      token,
+		URL:        s.config.BaseURL + "/preview/" + token,
+		PostID:     postID,
+		RevisionID: revision.ID,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// Resolve 校验预览令牌，返回文章和令牌绑定的版本
+// 文章已删除或版本已被清理时同样视为无效
+func (s *PreviewService) Resolve(tokenString string) (*model.Post, *model.PostRevision, error) {
+	token, err := jwt.Parse(tokenString, s.keys.Keyfunc, jwt.WithValidMethods(s.keys.ValidMethods()))
+	if err != nil || !token.Valid {
+		return nil, nil, ErrInvalidPreviewToken
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != "preview" {
+		return nil, nil, ErrInvalidPreviewToken
+	}
+	postID, ok1 := claims["post_id"].(float64)
+	revisionID, ok2 := claims["revision_id"].(float64)
+	if !ok1 || !ok2 {
+		return nil, nil, ErrInvalidPreviewToken
+	}
+	post, err := s.postRepo.GetByID(uint(postID))
+	if err != nil {
+		return nil, nil, previewLookupError(err)
+	}
+	revision, err := s.revisionRepo.GetByID(post.ID, uint(revisionID))
+	if err != nil {
+		return nil, nil, previewLookupError(err)
+	}
+	return post, revision, nil
+}
+
+func previewLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidPreviewToken
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"crist-blog/internal/keyset"
+	"crist-blog/internal/repository"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+func newTestPreviewService(db *gorm.DB, keys *keyset.KeySet) *PreviewService {
+	return NewPreviewService(repository.NewPostRepository(db), repository.NewPostRevisionRepository(db), keys,
+		newTestAuditService(db), PreviewConfig{BaseURL: "https://blog.example.com", DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour})
+}
+
+func TestPreviewLinkIsBoundToRevision(t *testing.T) {
+	db := newTestDB(t)
+	posts := newTestPostService(db, PostConfig{})
+	svc := newTestPreviewService(db, newTestKeySet(t))
+	author := createTestUser(t, db, "alice")
+	actor := Actor{UserID: author.ID}
+	post := createTestPost(t, posts, author, "Draft")
+
+	link, err := svc.CreateLink(actor, post.ID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.URL != "https://blog.example.com/preview/"+link.Token {
+		t.Fatalf("URL = %q", link.URL)
+	}
+	post.Content = "changed after the link was shared"
+	if err := posts.Update(actor, post); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, revision, err := svc.Resolve(link.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.ID != post.ID || revision.ID != link.RevisionID || revision.Content != "Draft content" {
+		t.Fatalf("resolved post %d revision %d %q, want the revision the link was created for", resolved.ID, revision.ID, revision.Content)
+	}
+
+	if _, err := svc.CreateLink(actor, post.ID, 0, 48*time.Hour); !errors.Is(err, ErrPreviewTTLTooLong) {
+		t.Fatalf("err = %v, want ErrPreviewTTLTooLong", err)
+	}
+}
+
+func TestPreviewResolveRejectsOtherTokens(t *testing.T) {
+	db := newTestDB(t)
+	posts := newTestPostService(db, PostConfig{})
+	keys := newTestKeySet(t)
+	svc := newTestPreviewService(db, keys)
+	author := createTestUser(t, db, "alice")
+	post := createTestPost(t, posts, author, "Draft")
+	other := createTestPost(t, posts, author, "Other")
+	link, err := svc.CreateLink(Actor{UserID: author.ID}, post.ID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := keys.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	now := time.Now()
+	tests := map[string]string{
+		"post unlock token": sign(jwt.MapClaims{"typ": "post_unlock", "post_id": post.ID, "revision_id": link.RevisionID,
+			"iat": now.Unix(), "exp": now.Add(time.Hour).Unix()}),
+		"expired": sign(jwt.MapClaims{"typ": "preview", "post_id": post.ID, "revision_id": link.RevisionID,
+			"iat": now.Add(-2 * time.Hour).Unix(), "exp": now.Add(-time.Hour).Unix()}),
+		"revision of another post": sign(jwt.MapClaims{"typ": "preview", "post_id": other.ID, "revision_id": link.RevisionID,
+			"iat": now.Unix(), "exp": now.Add(time.Hour).Unix()}),
+		"signed by another key": func() string {
+			token, err := newTestKeySet(t).Sign(jwt.MapClaims{"typ": "preview", "post_id": post.ID, "revision_id": link.RevisionID,
+				"iat": now.Unix(), "exp": now.Add(time.Hour).Unix()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}(),
+		"malformed": "not-a-token",
+	}
+	for name, token := range tests {
+		if _, _, err := svc.Resolve(token); !errors.Is(err, ErrInvalidPreviewToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidPreviewToken", name, err)
+		}
+	}
+}
+
+func TestPreviewLinkInvalidAfterPostDeleted(t *testing.T) {
+	db := newTestDB(t)
+	posts := newTestPostService(db, PostConfig{})
+	svc := newTestPreviewService(db, newTestKeySet(t))
+	author := createTestUser(t, db, "alice")
+	actor := Actor{UserID: author.ID}
+	post := createTestPost(t, posts, author, "Draft")
+	link, err := svc.CreateLink(actor, post.ID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := posts.Delete(actor, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Resolve(link.Token); !errors.Is(err, ErrInvalidPreviewToken) {
+		t.Fatalf("err = %v, want ErrInvalidPreviewToken for a trashed post", err)
+	}
+}
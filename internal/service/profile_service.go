@@ -0,0 +1,226 @@
+package service
+
+import (
+	"crist-blog/internal/mailer"
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidNickname = errors.New("昵称长度需在 1-32 个字符之间")
+	ErrInvalidAvatar   = errors.New("头像必须是 http 或 https 链接")
+	ErrBioTooLong      = errors.New("个人简介不能超过 500 个字符")
+	ErrAuthorNotFound  = errors.New("作者不存在")
+)
+
+const (
+	maxNicknameLength = 32
+	maxBioLength      = 500
+	maxAvatarLength   = 2048
+)
+
+// ProfileConfig 个人资料相关的可配置项
+type ProfileConfig struct {
+	// BaseURL 前端地址，用于拼接邮箱确认链接
+	BaseURL string
+	// VerificationTTL 新邮箱确认链接有效期
+	VerificationTTL time.Duration
+}
+
+type ProfileService struct {
+	userRepo      *repository.UserRepository
+	postRepo      *repository.PostRepository
+	userTokenRepo *repository.UserTokenRepository
+	mailer        mailer.Mailer
+	audit         *AuditService
+	config        ProfileConfig
+}
+
+func NewProfileService(
+	userRepo *repository.UserRepository,
+	postRepo *repository.PostRepository,
+	userTokenRepo *repository.UserTokenRepository,
+	mailer mailer.Mailer,
+	audit *AuditService,
+	config ProfileConfig) *ProfileService {
+	return &ProfileService{
+		userRepo:      userRepo,
+		postRepo:      postRepo,
+		userTokenRepo: userTokenRepo,
+		mailer:        mailer,
+		audit:         audit,
+		config:        config,
+	}
+}
+
+// GetProfile 返回当前用户的资料及待确认的新邮箱
+func (s *ProfileService) GetProfile(userID uuid.UUID) (*model.Profile, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	profile := &model.Profile{User: user}
+	if pending, err := s.userTokenRepo.FindActive(userID, model.TokenPurposeChangeEmail); err == nil {
+		profile.PendingEmail = pending.Payload
+	}
+	return profile, nil
+}
+
+// UpdateProfile 修改个人资料
+// 昵称、头像和简介立即生效；修改邮箱时先向新邮箱发送确认链接，确认后才替换原邮箱
+func (s *ProfileService) UpdateProfile(actor Actor, req *model.UpdateProfileRequest) (*model.Profile, error) {
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if req.Nickname != nil {
+		nickname := strings.TrimSpace(*req.Nickname)
+		if n := utf8.RuneCountInString(nickname); n < 1 || n > maxNicknameLength {
+			return nil, ErrInvalidNickname
+		}
+		fields["nickname"] = nickname
+	}
+	if req.Avatar != nil {
+		avatar := strings.TrimSpace(*req.Avatar)
+		if avatar != "" && !isHTTPURL(avatar) {
+			return nil, ErrInvalidAvatar
+		}
+		fields["avatar"] = avatar
+	}
+	if req.Bio != nil {
+		bio := strings.TrimSpace(*req.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return nil, ErrBioTooLong
+		}
+		fields["bio"] = bio
+	}
+
+	var newEmail string
+	if req.Email != nil {
+		email, err := normalizeEmail(*req.Email)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(email, user.Email) {
+			exists, err := s.userRepo.ExistsByEmail(email)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				return nil, ErrUserExists
+			}
+			newEmail = email
+		}
+	}
+
+	if len(fields) > 0 {
+		if err := s.userRepo.UpdateProfile(user.ID, fields); err != nil {
+			return nil, err
+		}
+		s.audit.Record(actor, model.AuditProfileUpdate, "user", user.ID.String(), nil)
+	}
+	if newEmail != "" {
+		if err := s.requestEmailChange(user, newEmail); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetProfile(user.ID)
+}
+
+// requestEmailChange 向新邮箱发送确认链接，新邮箱保存在令牌的附加数据中
+func (s *ProfileService) requestEmailChange(user *model.User, email string) error {
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := s.userTokenRepo.InvalidateByUserID(user.ID, model.TokenPurposeChangeEmail); err != nil {
+		return err
+	}
+	err = s.userTokenRepo.Create(&model.UserToken{
+		UserID:    user.ID,
+		Purpose:   model.TokenPurposeChangeEmail,
+		TokenHash: hashToken(token),
+		Payload:   email,
+		ExpiresAt: time.Now().Add(s.config.VerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/confirm-email?token=%s", strings.TrimRight(s.config.BaseURL, "/"), url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      email,
+		Subject: "请确认你的新邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n你申请将账号邮箱修改为 %s，请在 %s 内点击以下链接确认：\n\n%s\n\n如果这不是你本人的操作，请忽略本邮件。\n",
+			user.Nickname, email, s.config.VerificationTTL, link),
+	})
+}
+
+// ConfirmEmailChange 使用新邮箱收到的令牌完成邮箱修改，并通知原邮箱
+func (s *ProfileService) ConfirmEmailChange(actor Actor, token string) (err error) {
+	ut, err := s.userTokenRepo.Consume(model.TokenPurposeChangeEmail, hashToken(token))
+	if err != nil {
+		return ErrInvalidToken
+	}
+	defer func() {
+		s.audit.Record(actor, model.AuditEmailChange, "user", ut.UserID.String(), err)
+	}()
+	user, err := s.userRepo.GetByID(ut.UserID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	// 发出链接后邮箱可能已被其他账号占用
+	exists, err := s.userRepo.ExistsByEmail(ut.Payload)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrUserExists
+	}
+	if err := s.userRepo.UpdateEmail(user.ID, ut.Payload); err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "你的账号邮箱已修改",
+		Body: fmt.Sprintf("%s，你好：\n\n你的账号邮箱已修改为 %s。\n\n如果这不是你本人的操作，请立即联系管理员。\n",
+			user.Nickname, ut.Payload),
+	})
+	if err != nil {
+		log.Printf("warning: failed to notify %s of email change: %v", user.Email, err)
+	}
+	return nil
+}
+
+// GetAuthor 返回作者及其已发布的文章
+func (s *ProfileService) GetAuthor(username string) (*model.User, []*model.Post, error) {
+	user, err := s.userRepo.GetByName(username)
+	if err != nil {
+		return nil, nil, ErrAuthorNotFound
+	}
+	posts, err := s.postRepo.ListPublishedByUserID(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, posts, nil
+}
+
+func isHTTPURL(value string) bool {
+	if len(value) > maxAvatarLength {
+		return false
+	}
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
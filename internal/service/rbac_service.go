@@ -0,0 +1,76 @@
+package service
+
+import (
+	"crist-blog/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// rolePermissionsTTL 角色权限表的缓存时间，修改数据库后最多延迟这么久生效
+const rolePermissionsTTL = time.Minute
+
+type RBACService struct {
+	userRepo *repository.UserRepository
+	roleRepo *repository.RoleRepository
+
+	mu       sync.RWMutex
+	roles    map[string]map[string]bool
+	loadedAt time.Time
+}
+
+func NewRBACService(userRepo *repository.UserRepository, roleRepo *repository.RoleRepository) *RBACService {
+	return &RBACService{
+		userRepo: userRepo,
+		roleRepo: roleRepo,
+	}
+}
+
+// rolePermissions 返回角色到权限集合的映射，过期后从数据库重新加载
+func (s *RBACService) rolePermissions() (map[string]map[string]bool, error) {
+	s.mu.RLock()
+	if s.roles != nil && time.Since(s.loadedAt) < rolePermissionsTTL {
+		roles := s.roles
+		s.mu.RUnlock()
+		return roles, nil
+	}
+	s.mu.RUnlock()
+
+	rows, err := s.roleRepo.ListRolePermissions()
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string]map[string]bool)
+	for _, row := range rows {
+		if roles[row.Role] == nil {
+			roles[row.Role] = make(map[string]bool)
+		}
+		roles[row.Role][row.Permission] = true
+	}
+
+	s.mu.Lock()
+	s.roles = roles
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return roles, nil
+}
+
+// RoleHasPermission 判断角色是否拥有某项权限
+func (s *RBACService) RoleHasPermission(role, permission string) (bool, error) {
+	roles, err := s.rolePermissions()
+	if err != nil {
+		return false, err
+	}
+	return roles[role][permission], nil
+}
+
+// UserHasPermission 判断用户当前角色是否拥有某项权限
+// 每次都读取用户的最新角色，角色变更立即生效
+func (s *RBACService) UserHasPermission(userID uuid.UUID, permission string) (bool, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return s.RoleHasPermission(user.Role, permission)
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"crist-blog/internal/mailer"
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrRegistrationDisabled = errors.New("注册已关闭，请使用邀请码注册")
+	ErrInvalidInvitation    = errors.New("邀请码无效或已过期")
+	ErrUserExists           = errors.New("用户名或邮箱已被使用")
+	ErrInvalidUsername      = errors.New("用户名只能包含字母、数字、下划线和连字符，长度 3-32")
+	ErrInvalidEmail         = errors.New("邮箱格式不正确")
+	ErrInvalidToken         = errors.New("链接无效或已过期")
+	ErrUnknownRole          = errors.New("角色不存在")
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,32}$`)
+
+// RegistrationConfig 注册相关的可配置项
+type RegistrationConfig struct {
+	// Enabled 是否开放公开注册，关闭后只能通过邀请码注册
+	Enabled bool
+	// BaseURL 前端地址，用于拼接邮件中的链接
+	BaseURL string
+	// VerificationTTL 邮箱验证链接有效期
+	VerificationTTL time.Duration
+	// InvitationTTL 邀请码默认有效期
+	InvitationTTL time.Duration
+	// PasswordPolicy 密码强度规则
+	PasswordPolicy PasswordPolicy
+}
+
+type RegistrationService struct {
+	userRepo       *repository.UserRepository
+	invitationRepo *repository.InvitationRepository
+	userTokenRepo  *repository.UserTokenRepository
+	roleRepo       *repository.RoleRepository
+	mailer         mailer.Mailer
+	audit          *AuditService
+	config         RegistrationConfig
+}
+
+func NewRegistrationService(
+	userRepo *repository.UserRepository,
+	invitationRepo *repository.InvitationRepository,
+	userTokenRepo *repository.UserTokenRepository,
+	roleRepo *repository.RoleRepository,
+	mailer mailer.Mailer,
+	audit *AuditService,
+	config RegistrationConfig) *RegistrationService {
+	return &RegistrationService{
+		userRepo:       userRepo,
+		invitationRepo: invitationRepo,
+		userTokenRepo:  userTokenRepo,
+		roleRepo:       roleRepo,
+		mailer:         mailer,
+		audit:          audit,
+		config:         config,
+	}
+}
+
+// Register 注册新用户
+// 带邀请码时使用邀请码上的角色，即使公开注册已关闭也可以注册；否则以 reader 角色注册
+// 注册成功后发送邮箱验证邮件，验证前无法登录
+func (s *RegistrationService) Register(req *model.RegisterRequest) (*model.User, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	if !usernamePattern.MatchString(req.Username) {
+		return nil, ErrInvalidUsername
+	}
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	req.Email = email
+	if err := s.config.PasswordPolicy.Validate(req.Password); err != nil {
+		return nil, err
+	}
+
+	var invitation *model.Invitation
+	if req.InviteCode != "" {
+		inv, err := s.invitationRepo.FindByCodeHash(hashToken(req.InviteCode))
+		if err != nil || inv.UsedAt != nil || time.Now().After(inv.ExpiresAt) {
+			return nil, ErrInvalidInvitation
+		}
+		if inv.Email != "" && !strings.EqualFold(inv.Email, req.Email) {
+			return nil, ErrInvalidInvitation
+		}
+		invitation = inv
+	} else if !s.config.Enabled {
+		return nil, ErrRegistrationDisabled
+	}
+
+	exists, err := s.userRepo.ExistsByUsernameOrEmail(req.Username, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrUserExists
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	user := &model.User{
+		ID:           uuid.New(),
+		Username:     req.Username,
+		PasswordHash: string(passwordHash),
+		Nickname:     req.Nickname,
+		Email:        req.Email,
+		Role:         model.RoleReader,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if user.Nickname == "" {
+		user.Nickname = user.Username
+	}
+
+	if invitation != nil {
+		user.Role = invitation.Role
+		if err := s.userRepo.CreateWithInvitation(user, invitation.ID); err != nil {
+			if errors.Is(err, repository.ErrInvitationUnavailable) {
+				return nil, ErrInvalidInvitation
+			}
+			return nil, err
+		}
+	} else if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+
+	// 邮件发送失败不影响注册结果，用户可以稍后重新发送
+	if err := s.sendVerification(user); err != nil {
+		log.Printf("warning: failed to send verification email to %s: %v", user.Email, err)
+	}
+	return user, nil
+}
+
+// sendVerification 作废旧的验证链接并发送新的验证邮件
+func (s *RegistrationService) sendVerification(user *model.User) error {
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := s.userTokenRepo.InvalidateByUserID(user.ID, model.TokenPurposeVerifyEmail); err != nil {
+		return err
+	}
+	err = s.userTokenRepo.Create(&model.UserToken{
+		UserID:    user.ID,
+		Purpose:   model.TokenPurposeVerifyEmail,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.config.VerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", strings.TrimRight(s.config.BaseURL, "/"), url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "请验证你的邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n请在 %s 内点击以下链接完成邮箱验证：\n\n%s\n\n如果这不是你本人的操作，请忽略本邮件。\n",
+			user.Nickname, s.config.VerificationTTL, link),
+	})
+}
+
+// VerifyEmail 使用邮件中的令牌完成邮箱验证
+func (s *RegistrationService) VerifyEmail(token string) error {
+	ut, err := s.userTokenRepo.Consume(model.TokenPurposeVerifyEmail, hashToken(token))
+	if err != nil {
+		return ErrInvalidToken
+	}
+	return s.userRepo.MarkEmailVerified(ut.UserID)
+}
+
+// ResendVerification 重新发送验证邮件
+// 为避免泄露邮箱是否注册，邮箱不存在或已验证时同样返回成功
+func (s *RegistrationService) ResendVerification(email string) error {
+	user, err := s.userRepo.GetByEmail(strings.TrimSpace(email))
+	if err != nil || user.EmailVerifiedAt != nil {
+		return nil
+	}
+	return s.sendVerification(user)
+}
+
+// CreateInvitation 创建邀请码，返回只展示一次的邀请码明文
+func (s *RegistrationService) CreateInvitation(actor Actor, req *model.CreateInvitationRequest) (code string, invitation *model.Invitation, err error) {
+	defer func() {
+		var targetID string
+		if invitation != nil {
+			targetID = invitation.ID.String()
+		}
+		s.audit.Record(actor, model.AuditInvitationCreate, "invitation", targetID, err)
+	}()
+	if err := checkRole(s.roleRepo, req.Role); err != nil {
+		return "", nil, err
+	}
+	if req.Email != "" {
+		if req.Email, err = normalizeEmail(req.Email); err != nil {
+			return "", nil, err
+		}
+	}
+	ttl := s.config.InvitationTTL
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Hour
+	}
+
+	code, err = randomToken(16)
+	if err != nil {
+		return "", nil, err
+	}
+	invitation = &model.Invitation{
+		CodeHash:  hashToken(code),
+		Role:      req.Role,
+		Email:     req.Email,
+		CreatedBy: actor.UserID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err = s.invitationRepo.Create(invitation); err != nil {
+		return "", nil, err
+	}
+	return code, invitation, nil
+}
+
+func (s *RegistrationService) ListInvitations() ([]*model.Invitation, error) {
+	return s.invitationRepo.List()
+}
+
+// normalizeEmail 校验邮箱格式并返回其中的纯地址部分
+// mail.ParseAddress 也接受 "Eve <eve@example.com>" 这类带显示名的写法，只保存地址本身，
+// 避免显示名进入收件人和邮箱唯一性比较
+func normalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return "", ErrInvalidEmail
+	}
+	return addr.Address, nil
+}
+
+// checkRole 校验角色是否存在
+func checkRole(roleRepo *repository.RoleRepository, role string) error {
+	roles, err := roleRepo.ListRoles()
+	if err != nil {
+		return err
+	}
+	for _, r := range roles {
+		if r.Name == role {
+			return nil
+		}
+	}
+	return ErrUnknownRole
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	cases := []struct {
+		in, want string
+		err      error
+	}{
+		{"alice@example.com", "alice@example.com", nil},
+		{"  alice@example.com ", "alice@example.com", nil},
+		{"Eve <eve@example.com>", "eve@example.com", nil},
+		{`"Eve" <eve@example.com>`, "eve@example.com", nil},
+		{"not an email", "", ErrInvalidEmail},
+		{"", "", ErrInvalidEmail},
+	}
+	for _, tc := range cases {
+		got, err := normalizeEmail(tc.in)
+		if !errors.Is(err, tc.err) || got != tc.want {
+			t.Errorf("normalizeEmail(%q) = %q, %v; want %q, %v", tc.in, got, err, tc.want, tc.err)
+		}
+	}
+}
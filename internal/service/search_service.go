@@ -0,0 +1,159 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"crist-blog/internal/tokenizer"
+	"errors"
+	"strings"
+)
+
+var ErrEmptySearchQuery = errors.New("搜索关键词不能为空")
+
+// maxSuggestions 输入提示最多返回的条数
+const maxSuggestions = 8
+
+// SearchConfig 全文检索相关的可配置项
+type SearchConfig struct {
+	// TextSearchConfig PostgreSQL 文本检索配置名，使用中文分词时应为 simple
+	TextSearchConfig string
+}
+
+type SearchService struct {
+	postRepo  *repository.PostRepository
+	tokenizer tokenizer.Tokenizer
+	config    SearchConfig
+}
+
+func NewSearchService(postRepo *repository.PostRepository, tokenizer tokenizer.Tokenizer, config SearchConfig) *SearchService {
+	return &SearchService{
+		postRepo:  postRepo,
+		tokenizer: tokenizer,
+		config:    config,
+	}
+}
+
+// IndexPost 重新计算文章的检索向量，文章创建或修改后调用
+// 标题和标签分词后连同它们的拼音作为 A 权重，摘要为 B，正文为 C
+func (s *SearchService) IndexPost(post *model.Post) error {
+	titleTokens := s.tokenizer.Index(post.Title)
+	pinyinTokens := tokenizer.Pinyin(post.Title)
+	for _, tag := range post.Tags {
+		titleTokens = append(titleTokens, s.tokenizer.Index(tag)...)
+		pinyinTokens = append(pinyinTokens, tokenizer.Pinyin(tag)...)
+	}
+	titleTokens = append(titleTokens, pinyinTokens...)
+
+	// 受密码保护的文章不索引正文，片段也只从摘要生成，避免通过搜索推测出正文内容
+	content, document := post.Content, post.Content
+	if post.Status == model.Protected {
+		content, document = "", post.Excerpt
+	}
+	return s.postRepo.UpdateSearchIndex(post.ID, s.config.TextSearchConfig,
+		strings.Join(titleTokens, " "),
+		strings.Join(s.tokenizer.Index(post.Excerpt), " "),
+		strings.Join(s.tokenizer.Index(content), " "),
+		strings.Join(pinyinTokens, " "),
+		headlineDocument(s.tokenizer, document))
+}
+
+// ReindexAll 重建全部文章的检索索引，更换分词器或检索配置后调用
+func (s *SearchService) ReindexAll() (int, error) {
+	ids, err := s.postRepo.ListAllIDs()
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		post, err := s.postRepo.GetByID(id)
+		if err != nil {
+			return i, err
+		}
+		if err := s.IndexPost(post); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+// Search 在已发布文章中全文检索，结果按相关度排序并带有高亮片段
+// 全文检索没有结果时退回到标题、标签和拼音的模糊匹配，以容忍错别字
+func (s *SearchService) Search(q *model.PostSearchQuery) ([]*model.PostSearchResult, int64, error) {
+	text := strings.TrimSpace(q.Text)
+	if text == "" {
+		return nil, 0, ErrEmptySearchQuery
+	}
+	terms := s.tokenizer.Query(text)
+	q.Text = strings.Join(terms, " ")
+	hits, total, err := s.postRepo.SearchPublished(s.config.TextSearchConfig, headlineOptions, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	fuzzy := false
+	if total == 0 {
+		q.Text = text
+		if hits, total, err = s.postRepo.FuzzySearchPublished(q); err != nil {
+			return nil, 0, err
+		}
+		fuzzy = true
+	}
+
+	ids := make([]uint, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	rows, err := s.postRepo.ListSearchResults(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 标题和模糊匹配的摘要片段在应用内高亮，使用去掉搜索语法符号后的检索词
+	var highlightTerms []string
+	for _, term := range strings.Fields(strings.Join(terms, " ")) {
+		if term = strings.Trim(term, `"-`); term != "" && !strings.EqualFold(term, "or") {
+			highlightTerms = append(highlightTerms, term)
+		}
+	}
+
+	// 读取结果时不保证顺序，按命中结果的相关度顺序重新排列
+	byID := make(map[uint]*model.PostSearchResult, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+	results := make([]*model.PostSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		row, ok := byID[hit.ID]
+		if !ok {
+			continue
+		}
+		row.Rank = hit.Rank
+		row.Fuzzy = fuzzy
+		row.Highlight = highlight(row.Title, highlightTerms)
+		if fuzzy {
+			row.Snippet = snippet(row.Excerpt, highlightTerms)
+		} else {
+			row.Snippet = renderHeadline(hit.Snippet)
+		}
+		results = append(results, row)
+	}
+	return results, total, nil
+}
+
+// Suggest 搜索框输入提示，先列出匹配的文章标题，再用标签补足
+func (s *SearchService) Suggest(text string) ([]*model.Suggestion, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return []*model.Suggestion{}, nil
+	}
+	suggestions, err := s.postRepo.SuggestTitles(text, maxSuggestions)
+	if err != nil {
+		return nil, err
+	}
+	if len(suggestions) < maxSuggestions {
+		tags, err := s.postRepo.SuggestTags(text, maxSuggestions-len(suggestions))
+		if err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, tags...)
+	}
+	return suggestions, nil
+}
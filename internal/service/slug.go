@@ -0,0 +1,105 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+var (
+	ErrInvalidSlug = errors.New("slug 只能包含小写字母、数字和连字符")
+	ErrSlugTaken   = errors.New("slug 已被使用")
+)
+
+const (
+	// maxSlugLength 自动生成的 slug 最大长度，超出部分在单词边界截断
+	maxSlugLength = 80
+	// maxSlugAttempts 自动生成 slug 时追加数字后缀的最大尝试次数
+	maxSlugAttempts = 100
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// slugify 把标题转换为 slug：英文转小写，汉字转为不带声调的拼音，其余字符作为分隔符
+// 例如 “Go 语言入门” → “go-yu-yan-ru-men”
+func slugify(title string) string {
+	args := pinyin.NewArgs()
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range title {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if syllables := pinyin.SinglePinyin(r, args); len(syllables) > 0 {
+				words = append(words, syllables[0])
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	slug := ""
+	for _, w := range words {
+		if len(slug)+len(w)+1 > maxSlugLength {
+			break
+		}
+		if slug != "" {
+			slug += "-"
+		}
+		slug += w
+	}
+	return slug
+}
+
+// assignSlug 校验客户端提供的 slug，未提供时根据标题生成，冲突时依次追加 -2、-3 等后缀
+// post.ID 为 0 表示新建文章
+func (s *PostService) assignSlug(post *model.Post) error {
+	if post.Slug != "" {
+		post.Slug = strings.ToLower(strings.TrimSpace(post.Slug))
+		if !slugPattern.MatchString(post.Slug) {
+			return ErrInvalidSlug
+		}
+		taken, err := s.PostRepo.SlugTaken(post.Slug, post.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrSlugTaken
+		}
+		return nil
+	}
+
+	base := slugify(post.Title)
+	if base == "" {
+		base = "post"
+	}
+	for i := 1; i <= maxSlugAttempts; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+		taken, err := s.PostRepo.SlugTaken(candidate, post.ID)
+		if err != nil {
+			return err
+		}
+		if !taken {
+			post.Slug = candidate
+			return nil
+		}
+	}
+	return ErrSlugTaken
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"Go 语言入门", "go-yu-yan-ru-men"},
+		{"C++ & Go 2.0", "c-go-2-0"},
+		{"  --  ", ""},
+		{"Ünïcode Ascii", "n-code-ascii"},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.title); got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestSlugifyTruncatesAtWordBoundary(t *testing.T) {
+	slug := slugify(strings.Repeat("abcdefghi ", 20))
+	if len(slug) > maxSlugLength {
+		t.Fatalf("slug length %d exceeds %d", len(slug), maxSlugLength)
+	}
+	if want := strings.TrimSuffix(strings.Repeat("abcdefghi-", 8), "-"); slug != want {
+		t.Fatalf("slug = %q, want %q", slug, want)
+	}
+}
+
+func TestAssignSlugAddsSuffixOnCollision(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+
+	var slugs []string
+	for range 3 {
+		slugs = append(slugs, createTestPost(t, svc, author, "Hello World").Slug)
+	}
+	want := []string{"hello-world", "hello-world-2", "hello-world-3"}
+	for i := range want {
+		if slugs[i] != want[i] {
+			t.Fatalf("slugs = %v, want %v", slugs, want)
+		}
+	}
+
+	// 空标题和只有标点的标题使用 post
+	if slug := createTestPost(t, svc, author, "!!!").Slug; slug != "post" {
+		t.Fatalf("slug = %q, want post", slug)
+	}
+}
+
+func TestAssignSlugValidatesExplicitSlug(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	createTestPost(t, svc, author, "Taken")
+
+	tests := []struct {
+		slug    string
+		want    string
+		wantErr error
+	}{
+		{" My-Post ", "my-post", nil},
+		{"bad slug", "", ErrInvalidSlug},
+		{"trailing-", "", ErrInvalidSlug},
+		{"taken", "", ErrSlugTaken},
+	}
+	for _, tt := range tests {
+		post := &model.Post{Title: "x", Slug: tt.slug}
+		err := svc.assignSlug(post)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("assignSlug(%q): err = %v, want %v", tt.slug, err, tt.wantErr)
+			continue
+		}
+		if err == nil && post.Slug != tt.want {
+			t.Errorf("assignSlug(%q) = %q, want %q", tt.slug, post.Slug, tt.want)
+		}
+	}
+}
+
+func TestAssignSlugSkipsTrashedPostsAndHistory(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	actor := Actor{UserID: author.ID}
+
+	trashed := createTestPost(t, svc, author, "Trashed")
+	if err := svc.Delete(actor, trashed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if slug := createTestPost(t, svc, author, "Trashed").Slug; slug != "trashed-2" {
+		t.Fatalf("slug = %q, want trashed-2: a trashed post keeps its slug until purged", slug)
+	}
+
+	renamed := createTestPost(t, svc, author, "Old Name")
+	renamed.Slug = "new-name"
+	if err := svc.Update(actor, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if slug := createTestPost(t, svc, author, "Old Name").Slug; slug != "old-name-2" {
+		t.Fatalf("slug = %q, want old-name-2: old slugs stay reserved for redirects", slug)
+	}
+}
+
+func TestUpdateSlugRedirectsFromHistory(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	actor := Actor{UserID: author.ID}
+	post := createTestPost(t, svc, author, "First")
+
+	post.Slug = "second"
+	if err := svc.Update(actor, post); err != nil {
+		t.Fatal(err)
+	}
+	got, redirect, err := svc.GetPublishedBySlug("first")
+	if err != nil || got != nil || redirect != "second" {
+		t.Fatalf("GetPublishedBySlug(first) = %v, %q, %v; want redirect to second", got, redirect, err)
+	}
+
+	// 改回用过的 slug 时删除对应的历史记录，当前 slug 不能同时出现在历史中
+	post.Slug = "first"
+	if err := svc.Update(actor, post); err != nil {
+		t.Fatal(err)
+	}
+	got, redirect, err = svc.GetPublishedBySlug("first")
+	if err != nil || got == nil || got.ID != post.ID || redirect != "" {
+		t.Fatalf("GetPublishedBySlug(first) = %v, %q, %v; want the post", got, redirect, err)
+	}
+	_, redirect, err = svc.GetPublishedBySlug("second")
+	if err != nil || redirect != "first" {
+		t.Fatalf("GetPublishedBySlug(second) = %q, %v; want redirect to first", redirect, err)
+	}
+	var history []string
+	if err := db.Model(&model.PostSlug{}).Order("slug").Pluck("slug", &history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0] != "second" {
+		t.Fatalf("slug history = %v, want [second]", history)
+	}
+}
+
+func TestUpdateRejectsSlugOwnedByAnotherPost(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestPostService(db, PostConfig{})
+	author := createTestUser(t, db, "alice")
+	actor := Actor{UserID: author.ID}
+	first := createTestPost(t, svc, author, "First")
+	second := createTestPost(t, svc, author, "Second")
+
+	first.Slug = "renamed"
+	if err := svc.Update(actor, first); err != nil {
+		t.Fatal(err)
+	}
+	second.Slug = "first"
+	if err := svc.Update(actor, second); !errors.Is(err, ErrSlugTaken) {
+		t.Fatalf("err = %v, want ErrSlugTaken for another post's old slug", err)
+	}
+}
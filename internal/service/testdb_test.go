@@ -0,0 +1,302 @@
+package service
+
+import (
+	"crist-blog/internal/denylist"
+	"crist-blog/internal/keyset"
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"crist-blog/internal/tokenizer"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+
+// sqliteUUID 生成 8-4-4-4-12 格式的随机 ID，代替 Postgres 的 gen_random_uuid()
+const sqliteUUID = `(lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-' || hex(randomblob(2)) || '-' || hex(randomblob(2)) || '-' || hex(randomblob(6))))`
+
+// sqliteNow 毫秒精度的当前时间，代替 Postgres 的 now()
+const sqliteNow = `(strftime('%Y-%m-%d %H:%M:%f', 'now'))`
+
+// testSchema 只包含测试涉及的表，字段与 migrations 中的 Postgres 定义对应
+var testSchema = []string{
+	`ATTACH DATABASE ':memory:' AS admin`,
+	`CREATE TABLE admin.roles (
+		name TEXT PRIMARY KEY,
+		description TEXT
+	)`,
+	`CREATE TABLE admin.role_permissions (
+		role TEXT NOT NULL,
+		permission TEXT NOT NULL,
+		PRIMARY KEY (role, permission)
+	)`,
+	`CREATE TABLE admin.users (
+		id TEXT PRIMARY KEY DEFAULT ` + sqliteUUID + `,
+		username TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		nickname TEXT,
+		email TEXT NOT NULL UNIQUE,
+		avatar TEXT,
+		bio TEXT,
+		is_admin BOOLEAN NOT NULL DEFAULT false,
+		role TEXT NOT NULL DEFAULT 'reader',
+		email_verified_at TIMESTAMP,
+		disabled_at TIMESTAMP,
+		password_reset_required BOOLEAN NOT NULL DEFAULT false,
+		totp_secret TEXT,
+		totp_enabled BOOLEAN NOT NULL DEFAULT false,
+		totp_last_step BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		deleted_at TIMESTAMP
+	)`,
+	`CREATE TABLE admin.user_identities (
+		id TEXT PRIMARY KEY DEFAULT ` + sqliteUUID + `,
+		user_id TEXT NOT NULL,
+		provider TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		email TEXT,
+		created_at TIMESTAMP DEFAULT ` + sqliteNow + `,
+		UNIQUE (provider, subject)
+	)`,
+	`CREATE TABLE admin.refresh_tokens (
+		id TEXT PRIMARY KEY DEFAULT ` + sqliteUUID + `,
+		user_id TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		family_id TEXT NOT NULL,
+		user_agent TEXT,
+		ip_address TEXT,
+		expires_at TIMESTAMP NOT NULL,
+		revoked BOOLEAN NOT NULL DEFAULT false,
+		created_at TIMESTAMP DEFAULT ` + sqliteNow + `
+	)`,
+	`CREATE TABLE admin.recovery_codes (
+		id TEXT PRIMARY KEY DEFAULT ` + sqliteUUID + `,
+		user_id TEXT NOT NULL,
+		code_hash TEXT NOT NULL,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT ` + sqliteNow + `
+	)`,
+	`CREATE TABLE admin.personal_access_tokens (
+		id TEXT PRIMARY KEY DEFAULT ` + sqliteUUID + `,
+		user_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		token_prefix TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		scopes TEXT,
+		last_used_at TIMESTAMP,
+		expires_at TIMESTAMP,
+		revoked_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT ` + sqliteNow + `
+	)`,
+	`CREATE TABLE admin.audit_logs (
+		id TEXT PRIMARY KEY DEFAULT ` + sqliteUUID + `,
+		actor_id TEXT,
+		actor_name TEXT,
+		action TEXT NOT NULL,
+		target_type TEXT,
+		target_id TEXT,
+		ip_address TEXT,
+		user_agent TEXT,
+		outcome TEXT NOT NULL,
+		detail TEXT,
+		created_at TIMESTAMP DEFAULT ` + sqliteNow + `
+	)`,
+	// 文章相关的表放在同一个附加库中，外键才能级联删除
+	`ATTACH DATABASE ':memory:' AS blog`,
+	`CREATE TABLE blog.posts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id TEXT NOT NULL,
+		title TEXT NOT NULL,
+		slug TEXT NOT NULL UNIQUE,
+		content TEXT,
+		excerpt TEXT,
+		status TEXT NOT NULL,
+		category_id TEXT NOT NULL,
+		tags TEXT,
+		views INTEGER DEFAULT 0,
+		likes INTEGER DEFAULT 0,
+		thumbnail TEXT,
+		published_at TIMESTAMP,
+		meta_title TEXT,
+		meta_description TEXT,
+		password_hash TEXT,
+		version INTEGER NOT NULL DEFAULT 1,
+		search_vector TEXT,
+		search_pinyin TEXT,
+		search_document TEXT,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		deleted_at TIMESTAMP
+	)`,
+	`CREATE TABLE blog.post_slugs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		post_id INTEGER NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+		slug TEXT NOT NULL UNIQUE,
+		created_at TIMESTAMP NOT NULL DEFAULT ` + sqliteNow + `
+	)`,
+	`CREATE TABLE blog.post_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		post_id INTEGER NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+		user_id TEXT,
+		title TEXT NOT NULL,
+		content TEXT,
+		excerpt TEXT,
+		tags TEXT,
+		meta_title TEXT,
+		meta_description TEXT,
+		created_at TIMESTAMP NOT NULL DEFAULT ` + sqliteNow + `
+	)`,
+	`CREATE TABLE blog.post_locks (
+		post_id INTEGER PRIMARY KEY REFERENCES posts (id) ON DELETE CASCADE,
+		user_id TEXT NOT NULL,
+		acquired_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE TABLE blog.post_autosaves (
+		post_id INTEGER NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+		user_id TEXT NOT NULL,
+		title TEXT,
+		content TEXT,
+		excerpt TEXT,
+		tags TEXT,
+		meta_title TEXT,
+		meta_description TEXT,
+		base_version INTEGER NOT NULL,
+		updated_at TIMESTAMP NOT NULL DEFAULT ` + sqliteNow + `,
+		PRIMARY KEY (post_id, user_id)
+	)`,
+	`PRAGMA foreign_keys = ON`,
+}
+
+// newTestDB 创建内存 SQLite 数据库，admin schema 通过 ATTACH 模拟
+// ATTACH 只对单个连接生效，因此限制连接池只有一个连接
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	for _, stmt := range testSchema {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("create schema: %v", err)
+		}
+	}
+	return db
+}
+
+func newTestKeySet(t *testing.T) *keyset.KeySet {
+	t.Helper()
+	key, err := keyset.Generate(keyset.AlgEdDSA, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keyset.New()
+	if err := ks.Add(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.SetSigningKey("test"); err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func newTestDenylist() denylist.Store {
+	return denylist.NewMemoryStore(time.Minute)
+}
+
+func newTestAuditService(db *gorm.DB) *AuditService {
+	return NewAuditService(repository.NewAuditLogRepository(db))
+}
+
+// newTestAuthService 创建使用测试数据库的 AuthService，未指定刷新令牌密钥时使用固定值
+func newTestAuthService(t *testing.T, db *gorm.DB, config AuthConfig) *AuthService {
+	t.Helper()
+	if config.RefreshTokenSecret == nil {
+		config.RefreshTokenSecret = []byte("secret")
+	}
+	return NewAuthService(
+		repository.NewUserRepository(db),
+		repository.NewRefreshTokenRepository(db),
+		repository.NewPersonalAccessTokenRepository(db),
+		newTestKeySet(t),
+		newTestDenylist(),
+		newTestAuditService(db),
+		config,
+	)
+}
+
+// createTestUser 写入一个已验证邮箱的用户，密码为 password
+func createTestUser(t *testing.T, db *gorm.DB, username string) *model.User {
+	t.Helper()
+	now := time.Now()
+	user := &model.User{
+		Username:        username,
+		PasswordHash:    string(testPasswordHash),
+		Email:           username + "@example.com",
+		EmailVerifiedAt: &now,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// grantPermissions 为角色写入权限
+func grantPermissions(t *testing.T, db *gorm.DB, role string, permissions ...string) {
+	t.Helper()
+	if err := db.Create(&model.Role{Name: role}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, permission := range permissions {
+		if err := db.Create(&model.RolePermission{Role: role, Permission: permission}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newTestPostService 创建使用测试数据库的 PostService
+// SQLite 不支持全文检索，更新检索索引会失败，PostService 只记录日志，不影响测试
+func newTestPostService(db *gorm.DB, config PostConfig) *PostService {
+	postRepo := repository.NewPostRepository(db)
+	search := NewSearchService(postRepo, tokenizer.None{}, SearchConfig{TextSearchConfig: "simple"})
+	return NewPostService(postRepo, repository.NewPostRevisionRepository(db), search, newTestAuditService(db), config)
+}
+
+// createTestPost 写入一篇属于 author 的已发布文章，slug 根据标题生成
+func createTestPost(t *testing.T, svc *PostService, author *model.User, title string) *model.Post {
+	t.Helper()
+	post := &model.Post{
+		UserID:     author.ID,
+		Title:      title,
+		Content:    title + " content",
+		Status:     model.Published,
+		CategoryID: uuid.New(),
+	}
+	if err := svc.CreatePost(Actor{UserID: author.ID, Username: author.Username}, post); err != nil {
+		t.Fatal(err)
+	}
+	return post
+}
+
+// auditActions 返回已写入的审计动作，按写入顺序排列
+func auditActions(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var actions []string
+	if err := db.Model(&model.AuditLog{}).Order("rowid").Pluck("action", &actions).Error; err != nil {
+		t.Fatal(err)
+	}
+	return actions
+}
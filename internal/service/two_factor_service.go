@@ -0,0 +1,299 @@
+package service
+
+import (
+	"crist-blog/internal/denylist"
+	"crist-blog/internal/keyset"
+	"crist-blog/internal/model"
+	"crist-blog/internal/ratelimit"
+	"crist-blog/internal/repository"
+	"crist-blog/internal/totp"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MFAChallengeExpire 两步登录中间令牌的有效期
+	MFAChallengeExpire = 5 * time.Minute
+	recoveryCodeCount  = 10
+	totpSkew           = 1
+	// maxChallengeAttempts 同一个中间令牌允许输错验证码的次数，超过后需要重新输入密码
+	maxChallengeAttempts = 5
+)
+
+var (
+	ErrTwoFactorEnabled    = errors.New("两步验证已启用")
+	ErrTwoFactorNotEnabled = errors.New("两步验证未启用")
+	ErrTwoFactorNotSetup   = errors.New("请先获取两步验证密钥")
+	ErrInvalidTwoFactor    = errors.New("验证码错误")
+	ErrInvalidMFAChallenge = errors.New("登录已超时，请重新登录")
+)
+
+type TwoFactorService struct {
+	userRepo          *repository.UserRepository
+	recoveryCodeRepo  *repository.RecoveryCodeRepository
+	refreshTokenRepo  *repository.RefreshTokenRepository
+	keys              *keyset.KeySet
+	denylist          denylist.Store
+	loginGuard        *LoginGuard
+	audit             *AuditService
+	challengeFailures *ratelimit.SlidingWindow
+	issuer            string
+}
+
+func NewTwoFactorService(
+	userRepo *repository.UserRepository,
+	recoveryCodeRepo *repository.RecoveryCodeRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	keys *keyset.KeySet,
+	denylist denylist.Store,
+	loginGuard *LoginGuard,
+	audit *AuditService,
+	issuer string) *TwoFactorService {
+	return &TwoFactorService{
+		userRepo:          userRepo,
+		recoveryCodeRepo:  recoveryCodeRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		keys:              keys,
+		denylist:          denylist,
+		loginGuard:        loginGuard,
+		audit:             audit,
+		challengeFailures: ratelimit.NewSlidingWindow(MFAChallengeExpire),
+		issuer:            issuer,
+	}
+}
+
+// Setup 为用户生成待确认的两步验证密钥，返回密钥和用于生成二维码的 otpauth 链接
+// 在 Enable 确认之前两步验证不会生效，重复调用会替换待确认的密钥
+func (s *TwoFactorService) Setup(userID uuid.UUID) (secret, uri string, err error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user.TOTPEnabled {
+		return "", "", ErrTwoFactorEnabled
+	}
+	secret, err = totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.userRepo.UpdateTOTP(user.ID, secret, false, 0); err != nil {
+		return "", "", err
+	}
+	return secret, totp.ProvisioningURI(s.issuer, user.Username, secret), nil
+}
+
+// Enable 用验证器应用生成的验证码确认密钥并启用两步验证，返回只展示一次的恢复码
+func (s *TwoFactorService) Enable(actor Actor, code string) (codes []string, err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditTwoFactorEnable, "user", actor.UserID.String(), err)
+	}()
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotSetup
+	}
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactor
+	}
+	if err := s.userRepo.UpdateTOTP(user.ID, user.TOTPSecret, true, step); err != nil {
+		return nil, err
+	}
+	return s.generateRecoveryCodes(user.ID)
+}
+
+// Disable 关闭两步验证，需要同时提供密码和验证码（或恢复码）
+func (s *TwoFactorService) Disable(actor Actor, password, code string) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditTwoFactorDisable, "user", actor.UserID.String(), err)
+	}()
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return ErrWrongPassword
+	}
+	if err := s.verifyCode(user, code); err != nil {
+		return err
+	}
+	return s.Reset(user.ID)
+}
+
+// RegenerateRecoveryCodes 作废旧恢复码并生成新的一组
+func (s *TwoFactorService) RegenerateRecoveryCodes(actor Actor, code string) (codes []string, err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditRecoveryCodesRegen, "user", actor.UserID.String(), err)
+	}()
+	user, err := s.userRepo.GetByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.verifyCode(user, code); err != nil {
+		return nil, err
+	}
+	return s.generateRecoveryCodes(user.ID)
+}
+
+// AdminReset 管理员为丢失验证设备的用户重置两步验证
+// 同时作废该用户所有刷新令牌，已登录的设备需要重新登录
+func (s *TwoFactorService) AdminReset(actor Actor, userID uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditTwoFactorReset, "user", userID.String(), err)
+	}()
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.Reset(userID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeAllByUserID(userID)
+}
+
+// Reset 清除用户的两步验证设置和恢复码
+func (s *TwoFactorService) Reset(userID uuid.UUID) error {
+	if err := s.userRepo.UpdateTOTP(userID, "", false, 0); err != nil {
+		return err
+	}
+	return s.recoveryCodeRepo.DeleteByUserID(userID)
+}
+
+// IssueChallenge 密码验证通过后签发两步登录的中间令牌
+// 该令牌只能用于 VerifyChallenge，不能作为访问令牌使用
+func (s *TwoFactorService) IssueChallenge(user *model.User) (string, error) {
+	now := time.Now()
+	return s.keys.Sign(jwt.MapClaims{
+		"typ":     "mfa",
+		"jti":     uuid.New().String(),
+		"user_id": user.ID.String(),
+		"iat":     now.Unix(),
+		"exp":     now.Add(MFAChallengeExpire).Unix(),
+	})
+}
+
+// VerifyChallenge 校验中间令牌和验证码（或恢复码），通过后返回用户
+// 验证码失败按用户计入 LoginGuard，与密码登录共用递增等待和锁定；
+// 中间令牌只能使用一次，成功或输错 maxChallengeAttempts 次后作废
+func (s *TwoFactorService) VerifyChallenge(challenge, code, ip string) (*model.User, error) {
+	jti, expiresAt, user, err := s.parseChallenge(challenge)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loginGuard.Reserve(user.Username, ip); err != nil {
+		return nil, err
+	}
+	if err := s.verifyCode(user, code); err != nil {
+		s.loginGuard.RecordFailure(user.Username)
+		if errors.Is(err, ErrInvalidTwoFactor) && s.challengeFailures.Add(jti) >= maxChallengeAttempts {
+			s.revokeChallenge(jti, expiresAt)
+		}
+		return nil, err
+	}
+	s.revokeChallenge(jti, expiresAt)
+	s.loginGuard.Release(user.Username, ip)
+	s.loginGuard.RecordSuccess(user.Username)
+	return user, nil
+}
+
+// parseChallenge 解析中间令牌，返回 jti、过期时间和对应用户
+func (s *TwoFactorService) parseChallenge(challenge string) (string, time.Time, *model.User, error) {
+	token, err := jwt.Parse(challenge, s.keys.Keyfunc, jwt.WithValidMethods(s.keys.ValidMethods()))
+	if err != nil || !token.Valid {
+		return "", time.Time{}, nil, ErrInvalidMFAChallenge
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != "mfa" {
+		return "", time.Time{}, nil, ErrInvalidMFAChallenge
+	}
+	jti, _ := claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if jti == "" || err != nil || exp == nil {
+		return "", time.Time{}, nil, ErrInvalidMFAChallenge
+	}
+	if revoked, err := s.denylist.Contains(jti); err != nil || revoked {
+		return "", time.Time{}, nil, ErrInvalidMFAChallenge
+	}
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return "", time.Time{}, nil, ErrInvalidMFAChallenge
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil || !user.TOTPEnabled {
+		return "", time.Time{}, nil, ErrInvalidMFAChallenge
+	}
+	return jti, exp.Time, user, nil
+}
+
+// revokeChallenge 将中间令牌加入黑名单直到其过期
+func (s *TwoFactorService) revokeChallenge(jti string, expiresAt time.Time) {
+	_ = s.denylist.Add(jti, time.Until(expiresAt))
+	s.challengeFailures.Reset(jti)
+}
+
+// verifyCode 校验 6 位验证码，其它格式的输入按恢复码处理
+func (s *TwoFactorService) verifyCode(user *model.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+		if !ok {
+			return ErrInvalidTwoFactor
+		}
+		advanced, err := s.userRepo.AdvanceTOTPStep(user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidTwoFactor
+		}
+		return nil
+	}
+
+	used, err := s.recoveryCodeRepo.Consume(user.ID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactor
+	}
+	return nil
+}
+
+func (s *TwoFactorService) generateRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := randomToken(5)
+		if err != nil {
+			return nil, err
+		}
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	if err := s.recoveryCodeRepo.ReplaceForUser(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode 忽略用户输入恢复码时的大小写和分隔符
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"crist-blog/internal/totp"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func newTestTwoFactorService(t *testing.T, db *gorm.DB, guardConfig LoginGuardConfig) *TwoFactorService {
+	t.Helper()
+	return NewTwoFactorService(
+		repository.NewUserRepository(db),
+		repository.NewRecoveryCodeRepository(db),
+		repository.NewRefreshTokenRepository(db),
+		newTestKeySet(t),
+		newTestDenylist(),
+		newTestLoginGuard(guardConfig),
+		newTestAuditService(db),
+		"Test",
+	)
+}
+
+// enableTestTOTP 为用户启用两步验证并返回密钥，TOTPLastStep 为 0
+func enableTestTOTP(t *testing.T, db *gorm.DB, user *model.User) string {
+	t.Helper()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(user).Updates(map[string]interface{}{"totp_secret": secret, "totp_enabled": true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	user.TOTPSecret, user.TOTPEnabled = secret, true
+	return secret
+}
+
+func issueTestChallenge(t *testing.T, svc *TwoFactorService, user *model.User) string {
+	t.Helper()
+	challenge, err := svc.IssueChallenge(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return challenge
+}
+
+func totpCodeAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestVerifyChallengeAcceptsCurrentCode(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestTwoFactorService(t, db, LoginGuardConfig{FreeAttempts: 100})
+	user := createTestUser(t, db, "alice")
+	secret := enableTestTOTP(t, db, user)
+	got, err := svc.VerifyChallenge(issueTestChallenge(t, svc, user), totpCodeAt(t, secret, time.Now()), "1.2.3.4")
+	if err != nil {
+		t.Fatalf("VerifyChallenge: %v", err)
+	}
+	if got.ID != user.ID {
+		t.Fatalf("got user %s, want %s", got.ID, user.ID)
+	}
+}
+
+func TestVerifyChallengeRejectsReplayedCode(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestTwoFactorService(t, db, LoginGuardConfig{FreeAttempts: 100})
+	user := createTestUser(t, db, "alice")
+	secret := enableTestTOTP(t, db, user)
+	code := totpCodeAt(t, secret, time.Now())
+	if _, err := svc.VerifyChallenge(issueTestChallenge(t, svc, user), code, "1.2.3.4"); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	// 同一时间步的验证码即使换一个中间令牌也不能再次使用
+	if _, err := svc.VerifyChallenge(issueTestChallenge(t, svc, user), code, "1.2.3.4"); !errors.Is(err, ErrInvalidTwoFactor) {
+		t.Fatalf("replayed code: got %v, want ErrInvalidTwoFactor", err)
+	}
+	// 上一个时间步的验证码仍在允许的时钟误差内，但早于已使用的时间步，同样拒绝
+	previous := totpCodeAt(t, secret, time.Now().Add(-totp.Period*time.Second))
+	if _, err := svc.VerifyChallenge(issueTestChallenge(t, svc, user), previous, "1.2.3.4"); !errors.Is(err, ErrInvalidTwoFactor) {
+		t.Fatalf("older code: got %v, want ErrInvalidTwoFactor", err)
+	}
+}
+
+func TestEnrollmentCodeCannotBeReplayedAtLogin(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestTwoFactorService(t, db, LoginGuardConfig{FreeAttempts: 100})
+	user := createTestUser(t, db, "alice")
+	enableTestTOTP(t, db, user)
+	if err := svc.Reset(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	secret, _, err := svc.Setup(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Enable(Actor{UserID: user.ID}, code); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	// 启用时确认用的验证码已被消耗，不能再用于登录
+	if _, err := svc.VerifyChallenge(issueTestChallenge(t, svc, user), code, "1.2.3.4"); !errors.Is(err, ErrInvalidTwoFactor) {
+		t.Fatalf("enrollment code at login: got %v, want ErrInvalidTwoFactor", err)
+	}
+}
+
+func TestVerifyChallengeIsSingleUse(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestTwoFactorService(t, db, LoginGuardConfig{FreeAttempts: 100})
+	user := createTestUser(t, db, "alice")
+	secret := enableTestTOTP(t, db, user)
+	challenge := issueTestChallenge(t, svc, user)
+	if _, err := svc.VerifyChallenge(challenge, totpCodeAt(t, secret, time.Now()), "1.2.3.4"); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	next := totpCodeAt(t, secret, time.Now().Add(totp.Period*time.Second))
+	if _, err := svc.VerifyChallenge(challenge, next, "1.2.3.4"); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Fatalf("reused challenge: got %v, want ErrInvalidMFAChallenge", err)
+	}
+}
+
+func TestVerifyChallengeRevokedAfterTooManyFailures(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestTwoFactorService(t, db, LoginGuardConfig{FreeAttempts: 100})
+	user := createTestUser(t, db, "alice")
+	secret := enableTestTOTP(t, db, user)
+	challenge := issueTestChallenge(t, svc, user)
+	for i := 0; i < maxChallengeAttempts; i++ {
+		if _, err := svc.VerifyChallenge(challenge, "000000", "1.2.3.4"); !errors.Is(err, ErrInvalidTwoFactor) {
+			t.Fatalf("attempt %d: got %v, want ErrInvalidTwoFactor", i+1, err)
+		}
+	}
+	if _, err := svc.VerifyChallenge(challenge, totpCodeAt(t, secret, time.Now()), "1.2.3.4"); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Fatalf("after %d failures: got %v, want ErrInvalidMFAChallenge", maxChallengeAttempts, err)
+	}
+}
+
+func TestVerifyChallengeCountsFailuresPerUser(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestTwoFactorService(t, db, LoginGuardConfig{
+		FreeAttempts: 1,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Minute,
+	})
+	user := createTestUser(t, db, "alice")
+	secret := enableTestTOTP(t, db, user)
+	if _, err := svc.VerifyChallenge(issueTestChallenge(t, svc, user), "000000", "1.2.3.4"); !errors.Is(err, ErrInvalidTwoFactor) {
+		t.Fatalf("got %v, want ErrInvalidTwoFactor", err)
+	}
+	// 换 IP 和中间令牌也不能绕过按用户的递增等待
+	var throttled *LoginThrottledError
+	if _, err := svc.VerifyChallenge(issueTestChallenge(t, svc, user), totpCodeAt(t, secret, time.Now()), "5.6.7.8"); !errors.As(err, &throttled) {
+		t.Fatalf("got %v, want *LoginThrottledError", err)
+	}
+}
+
+func TestVerifyChallengeRecoveryCodeIsSingleUse(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestTwoFactorService(t, db, LoginGuardConfig{FreeAttempts: 100})
+	user := createTestUser(t, db, "alice")
+	enableTestTOTP(t, db, user)
+	codes, err := svc.generateRecoveryCodes(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.VerifyChallenge(issueTestChallenge(t, svc, user), codes[0], "1.2.3.4"); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if _, err := svc.VerifyChallenge(issueTestChallenge(t, svc, user), codes[0], "1.2.3.4"); !errors.Is(err, ErrInvalidTwoFactor) {
+		t.Fatalf("reused recovery code: got %v, want ErrInvalidTwoFactor", err)
+	}
+}
+
+func TestAdminReset(t *testing.T) {
+	db := newTestDB(t)
+	svc := newTestTwoFactorService(t, db, LoginGuardConfig{FreeAttempts: 100})
+	user := createTestUser(t, db, "alice")
+	enableTestTOTP(t, db, user)
+	auth := newTestAuthService(t, db, AuthConfig{MaxSessionsPerUser: 5})
+	if _, _, err := auth.GenerateTokens(user, "test", "1.2.3.4"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.AdminReset(Actor{Username: "admin"}, user.ID); err != nil {
+		t.Fatalf("AdminReset: %v", err)
+	}
+	var saved model.User
+	if err := db.First(&saved, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.TOTPEnabled || saved.TOTPSecret != "" {
+		t.Fatal("two-factor settings were not cleared")
+	}
+	if active := activeRefreshTokens(t, db, user); active != 0 {
+		t.Fatalf("%d refresh tokens still active", active)
+	}
+
+	missing := user.ID
+	missing[0] ^= 0xff
+	if err := svc.AdminReset(Actor{Username: "admin"}, missing); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("missing user: got %v, want ErrUserNotFound", err)
+	}
+}
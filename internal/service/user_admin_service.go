@@ -0,0 +1,238 @@
+package service
+
+import (
+	"crist-blog/internal/model"
+	"crist-blog/internal/repository"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound     = errors.New("用户不存在")
+	ErrCannotModifySelf = errors.New("不能对自己的账号执行该操作")
+)
+
+// UserAdminService 管理员对用户的增删改查
+type UserAdminService struct {
+	userRepo         *repository.UserRepository
+	refreshTokenRepo *repository.RefreshTokenRepository
+	roleRepo         *repository.RoleRepository
+	passwordService  *PasswordService
+	loginGuard       *LoginGuard
+	policy           PasswordPolicy
+	audit            *AuditService
+}
+
+func NewUserAdminService(
+	userRepo *repository.UserRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	roleRepo *repository.RoleRepository,
+	passwordService *PasswordService,
+	loginGuard *LoginGuard,
+	policy PasswordPolicy,
+	audit *AuditService) *UserAdminService {
+	return &UserAdminService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		roleRepo:         roleRepo,
+		passwordService:  passwordService,
+		loginGuard:       loginGuard,
+		policy:           policy,
+		audit:            audit,
+	}
+}
+
+func (s *UserAdminService) List(filter model.UserFilter, page, pageSize int) ([]*model.User, int64, error) {
+	return s.userRepo.List(filter, (page-1)*pageSize, pageSize)
+}
+
+// Get 按ID查找用户，包括已删除的用户
+func (s *UserAdminService) Get(id uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.GetByIDUnscoped(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// Create 创建用户，管理员创建的用户邮箱视为已验证
+// 未提供密码时生成随机密码，并要求用户通过邮件链接自行设置
+func (s *UserAdminService) Create(actor Actor, req *model.CreateUserRequest) (user *model.User, err error) {
+	defer func() {
+		targetID := ""
+		if user != nil {
+			targetID = user.ID.String()
+		}
+		s.audit.Record(actor, model.AuditUserCreate, "user", targetID, err)
+	}()
+
+	req.Username = strings.TrimSpace(req.Username)
+	if !usernamePattern.MatchString(req.Username) {
+		return nil, ErrInvalidUsername
+	}
+	if req.Email, err = normalizeEmail(req.Email); err != nil {
+		return nil, err
+	}
+	if req.Role == "" {
+		req.Role = model.RoleReader
+	}
+	if err := checkRole(s.roleRepo, req.Role); err != nil {
+		return nil, err
+	}
+	password := req.Password
+	if password == "" {
+		if password, err = randomToken(32); err != nil {
+			return nil, err
+		}
+	} else if err := s.policy.Validate(password); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.userRepo.ExistsByUsernameOrEmail(req.Username, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrUserExists
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	user = &model.User{
+		ID:              uuid.New(),
+		Username:        req.Username,
+		PasswordHash:    string(passwordHash),
+		Nickname:        req.Nickname,
+		Email:           req.Email,
+		Role:            req.Role,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if user.Nickname == "" {
+		user.Nickname = user.Username
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	if req.Password == "" {
+		if err := s.passwordService.ForceReset(user); err != nil {
+			return user, err
+		}
+		user.PasswordResetRequired = true
+	}
+	return user, nil
+}
+
+// Disable 停用用户并撤销其全部会话，已签发的访问令牌由 AuthMiddleware 拒绝
+func (s *UserAdminService) Disable(actor Actor, id uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditUserDisable, "user", id.String(), err)
+	}()
+	if id == actor.UserID {
+		return ErrCannotModifySelf
+	}
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.userRepo.SetDisabled(id, &now); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeAllByUserID(id)
+}
+
+func (s *UserAdminService) Enable(actor Actor, id uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditUserEnable, "user", id.String(), err)
+	}()
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	return s.userRepo.SetDisabled(id, nil)
+}
+
+// Unlock 解除用户因登录失败过多而触发的锁定
+func (s *UserAdminService) Unlock(actor Actor, id uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditUserUnlock, "user", id.String(), err)
+	}()
+	user, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	s.loginGuard.Unlock(user.Username)
+	return nil
+}
+
+// Delete 软删除用户并撤销其全部会话，可通过 Restore 恢复
+func (s *UserAdminService) Delete(actor Actor, id uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditUserDelete, "user", id.String(), err)
+	}()
+	if id == actor.UserID {
+		return ErrCannotModifySelf
+	}
+	deleted, err := s.userRepo.SoftDelete(id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrUserNotFound
+	}
+	return s.refreshTokenRepo.RevokeAllByUserID(id)
+}
+
+func (s *UserAdminService) Restore(actor Actor, id uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditUserRestore, "user", id.String(), err)
+	}()
+	restored, err := s.userRepo.Restore(id)
+	if err != nil {
+		return err
+	}
+	if restored == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// ForcePasswordReset 要求用户重置密码
+func (s *UserAdminService) ForcePasswordReset(actor Actor, id uuid.UUID) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditUserForceReset, "user", id.String(), err)
+	}()
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	return s.passwordService.ForceReset(user)
+}
+
+// SetRole 修改用户角色，RBACService 每次读取用户最新角色，修改立即生效
+// 管理员不能修改自己的角色，避免误操作后系统中没有管理员
+func (s *UserAdminService) SetRole(actor Actor, id uuid.UUID, role string) (err error) {
+	defer func() {
+		s.audit.Record(actor, model.AuditUserRoleChange, "user", id.String(), err)
+	}()
+	if id == actor.UserID {
+		return ErrCannotModifySelf
+	}
+	if err := checkRole(s.roleRepo, role); err != nil {
+		return err
+	}
+	if _, err := s.userRepo.GetByID(id); err != nil {
+		return ErrUserNotFound
+	}
+	return s.userRepo.UpdateRole(id, role)
+}
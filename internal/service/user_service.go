@@ -8,24 +8,81 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrInvalidCredentials 用户不存在和密码错误统一返回该错误，避免泄露用户名是否存在
+var ErrInvalidCredentials = errors.New("用户名或密码错误")
+
+var ErrPasswordResetRequired = errors.New("管理员要求重置密码，请通过邮件中的链接设置新密码")
+
+var ErrEmailNotVerified = errors.New("邮箱尚未验证")
+
+// dummyPasswordHash 用户不存在时仍与之比较一次，使响应时间与密码错误时一致
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("crist-blog-dummy-password"), bcrypt.DefaultCost)
+
+// UserConfig 用户相关的可配置项
+type UserConfig struct {
+	// RequireEmailVerification 为 true 时邮箱未验证的用户无法登录
+	RequireEmailVerification bool
+}
+
 type UserService struct {
-	userRepo *repository.UserRepository
+	userRepo   *repository.UserRepository
+	loginGuard *LoginGuard
+	audit      *AuditService
+	config     UserConfig
 }
 
-func NewUserService(userRepo *repository.UserRepository) *UserService {
+func NewUserService(userRepo *repository.UserRepository, loginGuard *LoginGuard, audit *AuditService, config UserConfig) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:   userRepo,
+		loginGuard: loginGuard,
+		audit:      audit,
+		config:     config,
 	}
 }
 
-func (s *UserService) Login(username, password string) (*model.User, error) {
-	user, err := s.userRepo.GetByName(username)
+// Login 校验用户名和密码，actor 为匿名请求方，失败时写入审计日志
+// 成功的登录在签发令牌时由 AuthService 记录
+// 同一用户名或 IP 失败过多时返回 *LoginThrottledError
+func (s *UserService) Login(actor Actor, username, password string) (user *model.User, err error) {
+	actor.Username = username
+	defer func() {
+		if err != nil {
+			s.audit.Record(actor, model.AuditLogin, "user", username, err)
+		}
+	}()
+
+	ip := actor.IP
+	if err := s.loginGuard.Reserve(username, ip); err != nil {
+		return nil, err
+	}
+
+	user, err = s.userRepo.GetByName(username)
 	if err != nil {
-		return nil, errors.New("用户不存在")
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		s.loginGuard.RecordFailure(username)
+		return nil, ErrInvalidCredentials
 	}
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 	if err != nil {
-		return nil, errors.New("密码错误")
+		s.loginGuard.RecordFailure(username)
+		return nil, ErrInvalidCredentials
+	}
+	s.loginGuard.Release(username, ip)
+	// 启用两步验证时，验证码通过后才清除失败记录
+	if !user.TOTPEnabled {
+		s.loginGuard.RecordSuccess(username)
+	}
+
+	// 以下检查放在密码校验之后，避免泄露账号状态
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
+	if s.config.RequireEmailVerification && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
 	}
 	return user, nil
 }
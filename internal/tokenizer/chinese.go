@@ -0,0 +1,71 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-ego/gse"
+	"github.com/mozillazg/go-pinyin"
+)
+
+// Chinese 基于 gse 词典的中文分词器，英文和数字按空白和标点切分
+type Chinese struct {
+	seg gse.Segmenter
+}
+
+// NewChinese 加载内置的简体中文词典，耗时约一秒，应在启动时创建一次并复用
+func NewChinese() (*Chinese, error) {
+	seg, err := gse.NewEmbed("zh_s")
+	if err != nil {
+		return nil, err
+	}
+	return &Chinese{seg: seg}, nil
+}
+
+// Index 使用搜索引擎模式切分，长词会额外输出其中的短词，
+// 例如“中华人民共和国”同时输出“中华”“人民”“共和国”
+func (t *Chinese) Index(text string) []string {
+	return normalize(t.seg.CutSearch(text, true))
+}
+
+// Query 使用精确模式切分，避免搜索词被拆成过多的子词
+func (t *Chinese) Query(text string) []string {
+	return normalize(t.seg.Cut(text, true))
+}
+
+// Segment 使用精确模式切分并保留空白和标点
+// gse 输出的英文已转为小写，这里按字符数从原文截取，保持原文的大小写
+func (t *Chinese) Segment(text string) []string {
+	runes := []rune(text)
+	parts := t.seg.Cut(text, true)
+	segments := make([]string, 0, len(parts))
+	pos := 0
+	for _, part := range parts {
+		n := utf8.RuneCountInString(part)
+		if pos+n > len(runes) || !strings.EqualFold(part, string(runes[pos:pos+n])) {
+			return []string{text}
+		}
+		segments = append(segments, string(runes[pos:pos+n]))
+		pos += n
+	}
+	if pos != len(runes) {
+		return []string{text}
+	}
+	return segments
+}
+
+// Pinyin 返回文本中汉字的全拼和首字母，如“中国” → ["zhongguo", "zg"]，不含汉字时返回 nil
+func Pinyin(text string) []string {
+	if !hasHan(text) {
+		return nil
+	}
+	syllables := pinyin.LazyPinyin(text, pinyin.NewArgs())
+	if len(syllables) == 0 {
+		return nil
+	}
+	var initials strings.Builder
+	for _, s := range syllables {
+		initials.WriteByte(s[0])
+	}
+	return []string{strings.Join(syllables, ""), initials.String()}
+}
@@ -0,0 +1,28 @@
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChineseSegmentPreservesText(t *testing.T) {
+	tk, err := NewChinese()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "我们使用 PostgreSQL 数据库，支持全文检索!\n\n第二段：中华人民共和国。"
+	segments := tk.Segment(text)
+	if strings.Join(segments, "") != text {
+		t.Fatalf("segments %q do not rebuild the text", segments)
+	}
+	found := map[string]bool{}
+	for _, s := range segments {
+		found[s] = true
+	}
+	// 原文大小写保持不变，汉字按词切分
+	for _, want := range []string{"PostgreSQL", "数据库"} {
+		if !found[want] {
+			t.Errorf("segment %q not found in %q", want, segments)
+		}
+	}
+}
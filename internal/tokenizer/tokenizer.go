@@ -0,0 +1,56 @@
+// Package tokenizer 将文章和搜索词切分为检索词，结果以空格拼接后交给 PostgreSQL 建立索引
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Tokenizer 检索分词器
+type Tokenizer interface {
+	// Index 切分待索引的文本，可以输出重叠的子词以提高召回
+	Index(text string) []string
+	// Query 切分搜索词，输出尽量少且不重叠的词，多个词之间为“与”的关系
+	Query(text string) []string
+	// Segment 把文本切分为首尾相接的片段，拼接后与原文完全相同，用于生成搜索结果片段
+	Segment(text string) []string
+}
+
+// New 按名称创建分词器：chinese 使用中文分词并附加拼音，none 不做处理，由 PostgreSQL 自行解析
+func New(name string) (Tokenizer, error) {
+	switch name {
+	case "chinese":
+		return NewChinese()
+	case "none", "":
+		return None{}, nil
+	}
+	return nil, fmt.Errorf("unknown tokenizer %q", name)
+}
+
+// None 原样返回文本
+type None struct{}
+
+func (None) Index(text string) []string { return []string{text} }
+
+func (None) Query(text string) []string { return []string{text} }
+
+func (None) Segment(text string) []string { return []string{text} }
+
+// normalize 转为小写并丢弃不含字母或数字的词（空白、标点等）
+func normalize(words []string) []string {
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) < 0 {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// hasHan 判断词中是否含有汉字
+func hasHan(word string) bool {
+	return strings.IndexFunc(word, func(r rune) bool { return unicode.Is(unicode.Han, r) }) >= 0
+}
@@ -0,0 +1,86 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret 为 RFC 6238 附录 B 中 SHA1 测试向量使用的密钥 "12345678901234567890"
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// 附录 B 给出的是 8 位验证码，6 位验证码取其后 6 位
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tc := range cases {
+		got, err := Code(rfcSecret, Step(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d): %v", tc.unix, err)
+		}
+		if got != tc.want {
+			t.Errorf("Code(%d) = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseSecret(t *testing.T) {
+	upper, _ := Code(rfcSecret, 1)
+	lower, err := Code(" "+strings.ToLower(rfcSecret)+" ", 1)
+	if err != nil || lower != upper {
+		t.Fatalf("lowercase secret: got %q, %v; want %q", lower, err, upper)
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	current := Step(now)
+	cases := []struct {
+		name   string
+		offset int64
+		ok     bool
+	}{
+		{"current step", 0, true},
+		{"previous step", -1, true},
+		{"next step", 1, true},
+		{"two steps ago", -2, false},
+		{"two steps ahead", 2, false},
+	}
+	for _, tc := range cases {
+		code, _ := Code(rfcSecret, current+tc.offset)
+		step, ok := Validate(rfcSecret, code, now, 1)
+		if ok != tc.ok {
+			t.Errorf("%s: ok = %v, want %v", tc.name, ok, tc.ok)
+			continue
+		}
+		// 返回匹配的时间步供调用方拒绝重放
+		if ok && step != current+tc.offset {
+			t.Errorf("%s: step = %d, want %d", tc.name, step, current+tc.offset)
+		}
+	}
+}
+
+func TestValidateRejectsMalformedCode(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, code := range []string{"", "28708", "2870822", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, now, 1); ok {
+			t.Errorf("Validate(%q) succeeded", code)
+		}
+	}
+	if _, ok := Validate(rfcSecret, " 287082 ", now, 0); !ok {
+		t.Error("surrounding whitespace should be ignored")
+	}
+	if _, ok := Validate("not base32!", "287082", now, 0); ok {
+		t.Error("invalid secret should fail")
+	}
+}
@@ -0,0 +1,10 @@
+-- 刷新令牌改为 HMAC-SHA256 哈希存储并按令牌族轮换
+-- 旧的 bcrypt 哈希无法按值查找，直接作废
+UPDATE admin.refresh_tokens SET revoked = true WHERE revoked = false;
+
+ALTER TABLE admin.refresh_tokens
+    ADD COLUMN IF NOT EXISTS family_id uuid NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE admin.refresh_tokens ALTER COLUMN family_id DROP DEFAULT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON admin.refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON admin.refresh_tokens (family_id);
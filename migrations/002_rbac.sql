@@ -0,0 +1,44 @@
+-- 基于角色的访问控制
+CREATE TABLE IF NOT EXISTS admin.roles (
+    name        text PRIMARY KEY,
+    description text
+);
+
+CREATE TABLE IF NOT EXISTS admin.role_permissions (
+    role       text NOT NULL REFERENCES admin.roles (name) ON DELETE CASCADE,
+    permission text NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO admin.roles (name, description) VALUES
+    ('admin',  '管理员，拥有全部权限'),
+    ('editor', '编辑，可管理所有文章和分类'),
+    ('author', '作者，可撰写并发布自己的文章'),
+    ('reader', '读者')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO admin.role_permissions (role, permission) VALUES
+    ('admin',  'post:create'),
+    ('admin',  'post:edit:own'),
+    ('admin',  'post:edit:any'),
+    ('admin',  'post:delete:own'),
+    ('admin',  'post:delete:any'),
+    ('admin',  'post:publish'),
+    ('admin',  'category:manage'),
+    ('admin',  'user:manage'),
+    ('editor', 'post:create'),
+    ('editor', 'post:edit:own'),
+    ('editor', 'post:edit:any'),
+    ('editor', 'post:delete:own'),
+    ('editor', 'post:delete:any'),
+    ('editor', 'post:publish'),
+    ('editor', 'category:manage'),
+    ('author', 'post:create'),
+    ('author', 'post:edit:own'),
+    ('author', 'post:delete:own'),
+    ('author', 'post:publish')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE admin.users
+    ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'reader' REFERENCES admin.roles (name);
+UPDATE admin.users SET role = 'admin' WHERE is_admin;
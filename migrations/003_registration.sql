@@ -0,0 +1,31 @@
+-- 注册、邀请码与邮箱验证
+ALTER TABLE admin.users ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+-- 已有账号均由管理员手动创建，视为已验证
+UPDATE admin.users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS admin.invitations (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    code_hash  text NOT NULL UNIQUE,
+    role       text NOT NULL REFERENCES admin.roles (name),
+    email      text,
+    created_by uuid NOT NULL REFERENCES admin.users (id),
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz,
+    used_by    uuid REFERENCES admin.users (id),
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS admin.user_tokens (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    uuid NOT NULL REFERENCES admin.users (id) ON DELETE CASCADE,
+    purpose    text NOT NULL,
+    token_hash text NOT NULL UNIQUE,
+    payload    text,
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON admin.user_tokens (user_id);
+
+INSERT INTO admin.role_permissions (role, permission) VALUES ('admin', 'user:invite')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,14 @@
+-- TOTP 两步验证
+ALTER TABLE admin.users
+    ADD COLUMN IF NOT EXISTS totp_secret    text,
+    ADD COLUMN IF NOT EXISTS totp_enabled   boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS totp_last_step bigint  NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS admin.recovery_codes (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    uuid NOT NULL REFERENCES admin.users (id) ON DELETE CASCADE,
+    code_hash  text NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON admin.recovery_codes (user_id);
@@ -0,0 +1,14 @@
+-- 个人访问令牌
+CREATE TABLE IF NOT EXISTS admin.personal_access_tokens (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      uuid NOT NULL REFERENCES admin.users (id) ON DELETE CASCADE,
+    name         text NOT NULL,
+    token_prefix text NOT NULL,
+    token_hash   text NOT NULL UNIQUE,
+    scopes       text[] NOT NULL DEFAULT '{}',
+    last_used_at timestamptz,
+    expires_at   timestamptz,
+    revoked_at   timestamptz,
+    created_at   timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON admin.personal_access_tokens (user_id);
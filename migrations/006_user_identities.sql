@@ -0,0 +1,11 @@
+-- 第三方登录绑定
+CREATE TABLE IF NOT EXISTS admin.user_identities (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    uuid NOT NULL REFERENCES admin.users (id) ON DELETE CASCADE,
+    provider   text NOT NULL,
+    subject    text NOT NULL,
+    email      text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT idx_user_identities_provider_subject UNIQUE (provider, subject)
+);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON admin.user_identities (user_id);
@@ -0,0 +1,32 @@
+-- 安全审计日志，只允许追加
+CREATE TABLE IF NOT EXISTS admin.audit_logs (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id    uuid,
+    actor_name  text,
+    action      text NOT NULL,
+    target_type text,
+    target_id   text,
+    ip_address  text,
+    user_agent  text,
+    outcome     text NOT NULL CHECK (outcome IN ('success', 'failure')),
+    detail      text,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON admin.audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON admin.audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON admin.audit_logs (action);
+
+CREATE OR REPLACE FUNCTION admin.audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'admin.audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_append_only ON admin.audit_logs;
+CREATE TRIGGER audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON admin.audit_logs
+    FOR EACH ROW EXECUTE FUNCTION admin.audit_logs_append_only();
+
+INSERT INTO admin.role_permissions (role, permission) VALUES
+    ('admin', 'audit:read')
+ON CONFLICT DO NOTHING;
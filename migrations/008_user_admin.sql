@@ -0,0 +1,4 @@
+-- 管理员停用账号、强制重置密码
+ALTER TABLE admin.users
+    ADD COLUMN IF NOT EXISTS disabled_at timestamptz,
+    ADD COLUMN IF NOT EXISTS password_reset_required boolean NOT NULL DEFAULT false;
@@ -0,0 +1,7 @@
+-- 文章列表查询使用的索引
+CREATE INDEX IF NOT EXISTS idx_posts_status_date ON blog.posts (status, (COALESCE(published_at, created_at)) DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_status_views ON blog.posts (status, views DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_status_likes ON blog.posts (status, likes DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_category_id ON blog.posts (category_id);
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON blog.posts (user_id);
+CREATE INDEX IF NOT EXISTS idx_posts_tags ON blog.posts USING gin (tags);
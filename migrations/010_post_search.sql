@@ -0,0 +1,11 @@
+-- 文章全文检索：标题、摘要、正文的权重依次为 A、B、C
+-- 新建和修改文章时由应用更新 search_vector，这里回填已有文章
+-- 配置名需与 SEARCH_TEXT_CONFIG 一致
+ALTER TABLE blog.posts ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+UPDATE blog.posts SET search_vector =
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(excerpt, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(content, '')), 'C');
+
+CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON blog.posts USING gin (search_vector);
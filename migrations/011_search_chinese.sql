@@ -0,0 +1,15 @@
+-- 中文分词与模糊搜索：search_vector 改由应用按分词结果写入（配置名需为 simple）
+-- search_pinyin 存放标题和标签的拼音，与标题、标签一起用于 pg_trgm 模糊匹配
+-- 执行后调用 POST /api/admin/search/reindex 按新分词器重建已有文章的索引
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE blog.posts ADD COLUMN IF NOT EXISTS search_pinyin text NOT NULL DEFAULT '';
+
+-- 表达式索引要求函数为 IMMUTABLE，array_to_string 本身只是 STABLE
+CREATE OR REPLACE FUNCTION blog.tags_text(tags text[]) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$ SELECT coalesce(array_to_string(tags, ' '), '') $$;
+
+CREATE INDEX IF NOT EXISTS idx_posts_title_trgm ON blog.posts USING gin (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_posts_tags_trgm ON blog.posts USING gin (blog.tags_text(tags) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_posts_search_pinyin_trgm ON blog.posts USING gin (search_pinyin gin_trgm_ops);
@@ -0,0 +1,10 @@
+-- 文章 slug 历史：修改 slug 后旧地址重定向到新地址
+-- 同一个 slug 不能同时被文章和历史记录占用，由应用在分配 slug 时检查
+CREATE TABLE IF NOT EXISTS blog.post_slugs (
+    id         bigserial PRIMARY KEY,
+    post_id    bigint NOT NULL REFERENCES blog.posts (id) ON DELETE CASCADE,
+    slug       text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT idx_post_slugs_slug UNIQUE (slug)
+);
+CREATE INDEX IF NOT EXISTS idx_post_slugs_post_id ON blog.post_slugs (post_id);
@@ -0,0 +1,20 @@
+-- 文章版本历史：每次保存记录一个快照，超出 POST_REVISION_LIMIT 的旧版本由应用删除
+CREATE TABLE IF NOT EXISTS blog.post_revisions (
+    id               bigserial PRIMARY KEY,
+    post_id          bigint NOT NULL REFERENCES blog.posts (id) ON DELETE CASCADE,
+    user_id          uuid REFERENCES admin.users (id) ON DELETE SET NULL,
+    title            text NOT NULL,
+    content          text,
+    excerpt          text,
+    tags             text[],
+    meta_title       text,
+    meta_description text,
+    created_at       timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_post_revisions_post_id ON blog.post_revisions (post_id, id DESC);
+
+-- 为已有文章记录当前内容作为第一个版本
+INSERT INTO blog.post_revisions (post_id, user_id, title, content, excerpt, tags, meta_title, meta_description, created_at)
+SELECT p.id, p.user_id, p.title, p.content, p.excerpt, p.tags, p.meta_title, p.meta_description, p.updated_at
+FROM blog.posts p
+WHERE NOT EXISTS (SELECT 1 FROM blog.post_revisions r WHERE r.post_id = p.id);
@@ -0,0 +1,3 @@
+-- 定时发布状态
+-- ADD VALUE 之后的语句不能在同一事务中使用新值，回填放在 015_scheduled_posts.sql
+ALTER TYPE post_status_enum ADD VALUE IF NOT EXISTS 'scheduled';
@@ -0,0 +1,6 @@
+-- 此前发布时间在将来的已发布文章会立即可见，改为定时发布
+UPDATE blog.posts SET status = 'scheduled'
+WHERE status = 'published' AND published_at > now() AND deleted_at IS NULL;
+
+-- 定时发布任务按发布时间查找到期的文章
+CREATE INDEX IF NOT EXISTS idx_posts_scheduled ON blog.posts (published_at) WHERE status = 'scheduled' AND deleted_at IS NULL;
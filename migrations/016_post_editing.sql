@@ -0,0 +1,23 @@
+-- 并发编辑：文章版本号（乐观锁）、编辑锁和每用户的自动保存草稿
+ALTER TABLE blog.posts ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS blog.post_locks (
+    post_id     bigint PRIMARY KEY REFERENCES blog.posts (id) ON DELETE CASCADE,
+    user_id     uuid NOT NULL REFERENCES admin.users (id) ON DELETE CASCADE,
+    acquired_at timestamptz NOT NULL,
+    expires_at  timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS blog.post_autosaves (
+    post_id          bigint NOT NULL REFERENCES blog.posts (id) ON DELETE CASCADE,
+    user_id          uuid NOT NULL REFERENCES admin.users (id) ON DELETE CASCADE,
+    title            text,
+    content          text,
+    excerpt          text,
+    tags             text[],
+    meta_title       text,
+    meta_description text,
+    base_version     integer NOT NULL,
+    updated_at       timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (post_id, user_id)
+);
@@ -0,0 +1,4 @@
+-- 受密码保护的文章：出现在列表中但只展示摘要，输入密码后才能查看正文
+ALTER TYPE post_status_enum ADD VALUE IF NOT EXISTS 'protected';
+
+ALTER TABLE blog.posts ADD COLUMN IF NOT EXISTS password_hash text;
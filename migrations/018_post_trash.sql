@@ -0,0 +1,4 @@
+-- 回收站：软删除的文章按删除时间列出，超过 POST_TRASH_RETENTION 后由后台任务永久删除
+-- 版本、slug 历史、编辑锁和自动保存均通过 ON DELETE CASCADE 随文章一起删除
+-- 文章缩略图只保存外部 URL，没有需要清理的本地媒体文件
+CREATE INDEX IF NOT EXISTS idx_posts_trashed ON blog.posts (deleted_at) WHERE deleted_at IS NOT NULL;
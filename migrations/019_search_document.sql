@@ -0,0 +1,4 @@
+-- 搜索结果片段由 ts_headline 生成：search_document 保存按分词结果切分后的正文（受密码保护的文章为摘要），
+-- 片段之间以零宽空格分隔，使 PostgreSQL 能在中文正文中定位检索词
+-- 执行后调用 POST /api/admin/search/reindex 为已有文章生成该列
+ALTER TABLE blog.posts ADD COLUMN IF NOT EXISTS search_document text NOT NULL DEFAULT '';